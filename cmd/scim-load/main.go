@@ -0,0 +1,71 @@
+// Command scim-load drives sustained create, get, list, patch and delete traffic for users and groups against a SCIM
+// server and reports the p50/p95/p99 latency, error rate and rate limiting (429) per endpoint.
+//
+//	scim-load -url https://path.to.scim/v2 -header "Authorization: Bearer token" -rate 50 -concurrency 10 -duration 1m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/load"
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+func main() {
+	var (
+		baseURL     = flag.String("url", "", "base url of the SCIM server, e.g. https://path.to.scim/v2")
+		rate        = flag.Float64("rate", 10, "maximum number of requests per second, 0 is unlimited")
+		duration    = flag.Duration("duration", time.Minute, "duration of the run")
+		concurrency = flag.Int("concurrency", 4, "number of concurrent workers")
+		retries     = flag.Int("retries", 3, "number of retries after a 429 (Too Many Requests)")
+		format      = flag.String("format", "text", "output format: text or json")
+		header      util.Headers
+	)
+	flag.Var(&header, "header", "header to add to every request, e.g. \"Authorization: Bearer token\" (repeatable)")
+	flag.Parse()
+
+	if *baseURL == "" {
+		log.Fatal("no url given")
+	}
+
+	// stop starting new iterations on an interrupt, the running ones still remove their users.
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	report := load.Run(ctx, load.Config{
+		URL:         *baseURL,
+		Rate:        *rate,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		MaxRetries:  *retries,
+		Middleware: func(req *http.Request) *http.Request {
+			header.Apply(req)
+			return req
+		},
+	})
+
+	var err error
+	switch *format {
+	case "text":
+		err = report.WriteText(os.Stdout)
+	case "json":
+		err = report.WriteJSON(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown format: %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
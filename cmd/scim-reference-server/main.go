@@ -0,0 +1,250 @@
+// Command scim-reference-server serves the reference server of the test suites, so it can be used as a local SCIM
+// server without writing Go.
+//
+//	scim-reference-server -addr :8080 -token secret -seed seed.json -data ./scim-data
+//	scim-reference-server -addr :8443 -tls-cert cert.pem -tls-key key.pem
+//
+// The seed file holds the resources to create on startup, by resource type name:
+//
+//	{"User": [{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "bjensen"}]}
+//
+// Users and groups that already exist with the same userName or displayName are not created again, so the seed file
+// can be used together with persisted resources.
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/optional"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", ":8080", "address to listen on")
+		certFile = flag.String("tls-cert", "", "certificate file, serves HTTPS together with -tls-key")
+		keyFile  = flag.String("tls-key", "", "private key file, serves HTTPS together with -tls-cert")
+		token    = flag.String("token", "", "bearer token that every request has to include (default no authentication)")
+		seed     = flag.String("seed", "", "JSON file with the resources to create on startup, by resource type name")
+		data     = flag.String("data", "", "directory to persist the resources in (default in memory)")
+		features = flag.String("features", "", "comma separated list of optional features to enable: bulk, search, sort, etag, changePassword, reset")
+		rate     = flag.Int("rate-limit", 0, "maximum number of requests per second, beyond it requests get a 429 (default no limit)")
+	)
+	flag.Parse()
+
+	if (*certFile == "") != (*keyFile == "") {
+		log.Fatal("both -tls-cert and -tls-key are required to serve HTTPS")
+	}
+
+	s := test.Server()
+	if *data != "" {
+		var err error
+		if s, err = test.PersistentServer(*data); err != nil {
+			log.Fatalf("failed loading resources: %v", err)
+		}
+	}
+	// the features are set once, before the server is seeded and serves requests.
+	if err := enable(s.Features, *features); err != nil {
+		log.Fatal(err)
+	}
+	s.Features.RateLimit = *rate
+	if *seed != "" {
+		if err := load(s, *seed); err != nil {
+			log.Fatalf("failed loading seed data: %v", err)
+		}
+	}
+
+	if *token != "" {
+		// advertise the bearer token, so the service provider configuration matches the authentication.
+		s.Config.AuthenticationSchemes = append(s.Config.AuthenticationSchemes, scim.AuthenticationScheme{
+			Type:        scim.AuthenticationTypeOauthBearerToken,
+			Name:        "OAuth Bearer Token",
+			Description: "Authentication scheme using the OAuth Bearer Token Standard.",
+			SpecURI:     optional.NewString("https://tools.ietf.org/html/rfc6750"),
+			Primary:     true,
+		})
+	}
+
+	var handler http.Handler = s
+	if *token != "" {
+		handler = authenticate(handler, *token)
+	}
+	handler = logRequests(handler)
+
+	log.Printf("listening on %s", *addr)
+	if *certFile != "" {
+		log.Fatal(http.ListenAndServeTLS(*addr, *certFile, *keyFile, handler))
+	}
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+// enable enables the given comma separated optional features.
+func enable(f *test.Features, features string) error {
+	for _, feature := range strings.Split(features, ",") {
+		switch strings.TrimSpace(feature) {
+		case "":
+		case "bulk":
+			f.Bulk = true
+		case "search":
+			f.Search = true
+		case "sort":
+			f.Sort = true
+		case "etag":
+			f.ETag = true
+		case "changePassword":
+			f.ChangePassword = true
+		case "reset":
+			f.Reset = true
+		default:
+			return fmt.Errorf("unknown feature: %q", feature)
+		}
+	}
+	return nil
+}
+
+// identifiers are the attributes that identify the resources of a resource type, by resource type name. Seed
+// resources are matched against the existing ones on these attributes, so they are not created again on a restart.
+var identifiers = map[string]string{
+	"User":  "userName",
+	"Group": "displayName",
+}
+
+// load creates the resources of the given seed file. Resources that already exist (e.g. in the persisted resources)
+// are skipped, resources that can not be created are logged and skipped.
+func load(s test.ReferenceServer, file string) error {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var seed map[string][]json.RawMessage
+	if err := json.Unmarshal(raw, &seed); err != nil {
+		return err
+	}
+
+	for name := range seed {
+		var known bool
+		for _, resourceType := range s.ResourceTypes {
+			known = known || resourceType.Name == name
+		}
+		if !known {
+			return fmt.Errorf("unknown resource type: %q", name)
+		}
+	}
+
+	// seed requests are not limited, they are sent to a copy of the server without a rate limit.
+	features := *s.Features
+	features.RateLimit = 0
+	s.Features = &features
+
+	// create the resources in the order of the resource types, so users exist before groups.
+	for _, resourceType := range s.ResourceTypes {
+		if len(seed[resourceType.Name]) == 0 {
+			continue
+		}
+		present, err := existing(resourceType)
+		if err != nil {
+			return err
+		}
+
+		var created, skipped int
+		for _, resource := range seed[resourceType.Name] {
+			if identifier, ok := identify(resourceType, resource); ok && present[identifier] {
+				skipped++
+				continue
+			}
+
+			req := httptest.NewRequest(http.MethodPost, resourceType.Endpoint, bytes.NewReader(resource))
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusCreated {
+				log.Printf("skipped %s: status code %d: %s", resourceType.Name, rec.Code, strings.TrimSpace(rec.Body.String()))
+				continue
+			}
+			created++
+		}
+		log.Printf("%s: created %d resource(s), %d already existed", resourceType.Name, created, skipped)
+	}
+	return nil
+}
+
+// existing returns the (lowercase) identifiers of the existing resources of the given resource type.
+func existing(resourceType scim.ResourceType) (map[string]bool, error) {
+	present := make(map[string]bool)
+	attribute, ok := identifiers[resourceType.Name]
+	if !ok {
+		return present, nil
+	}
+	req := httptest.NewRequest(http.MethodGet, resourceType.Endpoint, nil)
+	page, err := resourceType.Handler.GetAll(req, scim.ListRequestParams{StartIndex: 1, Count: math.MaxInt32})
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range page.Resources {
+		if value, ok := resource.Attributes[attribute].(string); ok {
+			present[strings.ToLower(value)] = true
+		}
+	}
+	return present, nil
+}
+
+// identify returns the (lowercase) identifier of the given seed resource, if it has one.
+func identify(resourceType scim.ResourceType, resource json.RawMessage) (string, bool) {
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(resource, &attributes); err != nil {
+		return "", false
+	}
+	value, ok := attributes[identifiers[resourceType.Name]].(string)
+	return strings.ToLower(value), ok
+}
+
+// authenticate only lets requests with the given bearer token through.
+func authenticate(next http.Handler, token string) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("Content-Type", "application/scim+json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(errors.ScimError{
+				Detail: "Authorization failure. The authorization header is invalid or missing.",
+				Status: http.StatusUnauthorized,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logRequests logs the method, path, status code and duration of every request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), sw.status, time.Since(start))
+	})
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,88 @@
+// Command scim-report converts the output of "go test -json" into a machine-readable conformance report or a
+// compliance matrix.
+//
+//	go test -json ./... | scim-report -format junit -o report.xml
+//	go test -json ./... | scim-report -format html -o matrix.html
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	suite "github.com/di-wu/scim-test-suite"
+	azure "github.com/di-wu/scim-test-suite/idp/azure_ad"
+	"github.com/di-wu/scim-test-suite/idp/okta"
+	"github.com/di-wu/scim-test-suite/report"
+)
+
+var references = map[string]report.References{
+	"scim":        suite.References,
+	"okta":        okta.References,
+	"okta-groups": okta.GroupPushReferences,
+	"azure":       azure.References,
+}
+
+func main() {
+	var (
+		format = flag.String("format", "json", "output format: json, junit, markdown or html")
+		output = flag.String("o", "", "output file (default stdout)")
+		suites = flag.String("suite", "scim", "comma separated list of suites that were run: scim, okta, okta-groups, azure; "+
+			"suites with tests of the same name are scoped by the top level test that runs them, e.g. scim=TestSCIM,okta=TestIdP")
+	)
+	flag.Parse()
+
+	var refs []report.References
+	for _, suite := range strings.Split(*suites, ",") {
+		name, test := strings.TrimSpace(suite), ""
+		if i := strings.Index(name, "="); i != -1 {
+			name, test = name[:i], name[i+1:]
+		}
+		r, ok := references[name]
+		if !ok {
+			log.Fatalf("unknown suite: %q", name)
+		}
+		if test != "" {
+			r = r.Scope(test)
+		}
+		refs = append(refs, r)
+	}
+	merged, err := report.Merge(refs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r, err := report.Parse(os.Stdin, merged)
+	if err != nil {
+		log.Fatalf("failed parsing test output: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("failed creating output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "json":
+		err = r.WriteJSON(w)
+	case "junit":
+		err = r.WriteJUnit(w)
+	case "markdown":
+		err = r.WriteMarkdown(w)
+	case "html":
+		err = r.WriteHTML(w)
+	default:
+		err = fmt.Errorf("unknown format: %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
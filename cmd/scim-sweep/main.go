@@ -0,0 +1,150 @@
+// Command scim-sweep removes the users and groups that were left behind by runs of the test suites, i.e. all resources
+// of which the name starts with the given prefix.
+//
+//	scim-sweep -url https://path.to.scim/v2 -header "Authorization: Bearer token" -prefix scimtest-
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// endpoints are the endpoints that get swept, in order, with the attribute that holds the (namespaced) name.
+var endpoints = []struct {
+	path, attribute string
+}{
+	{path: "/Groups", attribute: "displayName"},
+	{path: "/Users", attribute: "userName"},
+}
+
+func main() {
+	var (
+		baseURL = flag.String("url", "", "base url of the SCIM server, e.g. https://path.to.scim/v2")
+		prefix  = flag.String("prefix", util.NamespacePrefix, "prefix of the resources to remove")
+		dryRun  = flag.Bool("dry-run", false, "only list the resources that would be removed")
+		header  util.Headers
+	)
+	flag.Var(&header, "header", "header to add to every request, e.g. \"Authorization: Bearer token\" (repeatable)")
+	flag.Parse()
+
+	if *baseURL == "" {
+		log.Fatal("no url given")
+	}
+	if *prefix == "" {
+		log.Fatal("refusing to sweep with an empty prefix")
+	}
+
+	s := sweeper{
+		url:     strings.TrimSuffix(*baseURL, "/"),
+		headers: header,
+		dryRun:  *dryRun,
+	}
+	for _, endpoint := range endpoints {
+		n, err := s.sweep(endpoint.path, endpoint.attribute, *prefix)
+		if err != nil {
+			log.Fatalf("failed sweeping %s: %v", endpoint.path, err)
+		}
+		if *dryRun {
+			log.Printf("%s: would remove %d resource(s)", endpoint.path, n)
+			continue
+		}
+		log.Printf("%s: removed %d resource(s)", endpoint.path, n)
+	}
+}
+
+type sweeper struct {
+	url     string
+	headers util.Headers
+	dryRun  bool
+}
+
+// sweep removes all resources of the given endpoint of which the attribute starts with the given prefix. It returns
+// the number of removed resources, or the number of resources that would be removed on a dry run.
+func (s sweeper) sweep(path, attribute, prefix string) (int, error) {
+	var (
+		removed    int
+		startIndex = 1
+	)
+	for {
+		query := url.Values{
+			"filter":     []string{fmt.Sprintf("%s sw \"%s\"", attribute, prefix)},
+			"startIndex": []string{fmt.Sprint(startIndex)},
+			"count":      []string{"100"},
+		}
+		resp, err := s.do(http.MethodGet, fmt.Sprintf("%s?%s", path, query.Encode()))
+		if err != nil {
+			return removed, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return removed, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		var list struct {
+			TotalResults int
+			Resources    []map[string]interface{}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		_ = resp.Body.Close()
+		if err != nil {
+			return removed, err
+		}
+		if len(list.Resources) == 0 {
+			return removed, nil
+		}
+
+		var kept int
+		for _, resource := range list.Resources {
+			id, _ := resource["id"].(string)
+			name, _ := resource[attribute].(string)
+			if id == "" || !strings.HasPrefix(name, prefix) {
+				// do not trust the filter of the server blindly.
+				kept++
+				continue
+			}
+
+			if s.dryRun {
+				log.Printf("%s/%s (%s)", path, id, name)
+				removed++
+				// the resource is not removed, so it is still part of the results.
+				kept++
+				continue
+			}
+
+			resp, err := s.do(http.MethodDelete, fmt.Sprintf("%s/%s", path, id))
+			if err != nil {
+				return removed, err
+			}
+			_ = resp.Body.Close()
+			// resources that are already removed are ignored.
+			if (resp.StatusCode < 200 || resp.StatusCode >= 300) && resp.StatusCode != http.StatusNotFound {
+				log.Printf("failed removing %s/%s (%s): status code %d", path, id, name, resp.StatusCode)
+				kept++
+				continue
+			}
+			removed++
+		}
+
+		// resources that were not removed are still part of the results, skip them.
+		startIndex += kept
+		if startIndex > list.TotalResults {
+			return removed, nil
+		}
+	}
+}
+
+func (s sweeper) do(method, path string) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.headers.Apply(req)
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,89 @@
+package suite
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.14
+
+// concurrentRequests is the number of requests that TestConcurrency sends at the same time.
+const concurrentRequests = 10
+
+func (suite *SCIMTestSuite) TestConcurrency() {
+	suite.Run("UserNameUniqueness", func() {
+		// Only one of the users with the same user name can be created, the others result in 409 (Conflict).
+		userName := suite.Namespaced("concurrent")
+		reqs := make([]*http.Request, concurrentRequests)
+		for i := range reqs {
+			reqs[i] = suite.NewRequest(http.MethodPost, "/Users", suite.userBody(userName))
+		}
+		results := suite.Concurrently(reqs...)
+		suite.Require().NoError(results.Err())
+		suite.Equal(1, results.Count(http.StatusCreated), "status codes: %v", results.StatusCodes())
+		suite.Equal(concurrentRequests-1, results.Count(http.StatusConflict), "status codes: %v", results.StatusCodes())
+	})
+
+	suite.Run("GroupMembers", func() {
+		// Members that are added at the same time all end up in the group, none of the updates get lost.
+		groupID := suite.createGroup("concurrent")
+		userIDs := make([]string, concurrentRequests)
+		reqs := make([]*http.Request, concurrentRequests)
+		for i := range reqs {
+			userIDs[i] = suite.createUser(fmt.Sprintf("concurrent%02d", i))
+			reqs[i] = suite.NewRequest(http.MethodPatch, fmt.Sprintf("/Groups/%s", groupID), suite.patchBody(
+				map[string]interface{}{
+					"op":   "add",
+					"path": "members",
+					"value": []map[string]interface{}{
+						{"value": userIDs[i]},
+					},
+				},
+			))
+		}
+		results := suite.Concurrently(reqs...)
+		suite.Require().NoError(results.Err())
+		for _, code := range results.StatusCodes() {
+			suite.Contains([]int{http.StatusOK, http.StatusNoContent}, code)
+		}
+
+		members := suite.members(groupID)
+		for _, id := range userIDs {
+			suite.True(hasValue(members, id), "member %s got lost", id)
+		}
+	})
+
+	suite.Run("IfMatch", func() {
+		// Only one of the replacements with the same version in the "If-Match" header succeeds, the others result in
+		// 412 (Precondition Failed) since the version has changed.
+		config := suite.ReadAllToMap(suite.GetOk("/ServiceProviderConfig"))
+		if !suite.GetBool("supported", suite.GetMap("etag", config)) {
+			suite.T().Skip("ETags are not supported")
+		}
+
+		var (
+			userName = suite.Namespaced("concurrent-etag")
+			id       = suite.createUser("concurrent-etag")
+			resp     = suite.GetOk(fmt.Sprintf("/Users/%s", id))
+			etag     = resp.Header.Get("ETag")
+		)
+		if etag == "" {
+			etag = suite.GetString("version", suite.GetMap("meta", suite.ReadAllToMap(resp)))
+		}
+		suite.Require().NotEmpty(etag, "the user has no version")
+
+		reqs := make([]*http.Request, concurrentRequests)
+		for i := range reqs {
+			user := util.UserBody(userName)
+			user["displayName"] = fmt.Sprintf("Concurrent %d", i)
+			reqs[i] = suite.NewRequest(http.MethodPut, fmt.Sprintf("/Users/%s", id), suite.Body(user))
+			reqs[i].Header.Set("If-Match", etag)
+		}
+		results := suite.Concurrently(reqs...)
+		suite.Require().NoError(results.Err())
+		suite.Equal(1, results.Count(http.StatusOK), "status codes: %v", results.StatusCodes())
+		suite.Equal(concurrentRequests-1, results.Count(http.StatusPreconditionFailed), "status codes: %v", results.StatusCodes())
+	})
+}
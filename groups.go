@@ -0,0 +1,139 @@
+package suite
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7643#section-4.2
+
+func (suite *SCIMTestSuite) TestGroupMembership() {
+	var (
+		userID  = suite.createUser("member")
+		groupID = suite.createGroup("members", map[string]interface{}{
+			"value": userID,
+		})
+	)
+
+	suite.Run("UnknownMember", func() {
+		// The value of a member is the identifier of a user or group, a member that does not exist can not be added.
+		resp := suite.Post("/Groups", suite.groupBody(suite.Namespaced("unknown"), map[string]interface{}{
+			"value": suite.Namespaced("unknown"),
+		}))
+		suite.Should().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	suite.Run("MemberType", func() {
+		// The type of a member is a label indicating the type of resource, i.e. "User" or "Group".
+		member := suite.member(groupID, userID)
+		suite.Require().NotNil(member, "user is not a member of the group")
+		if memberType, ok := member["type"]; ok {
+			suite.Equal("User", memberType)
+		}
+	})
+
+	suite.Run("Reference", func() {
+		// The "$ref" of a member is the URI of the SCIM resource that is a member of the group.
+		member := suite.member(groupID, userID)
+		suite.Require().NotNil(member, "user is not a member of the group")
+		ref, ok := member["$ref"].(string)
+		if !ok {
+			suite.T().Skip("members have no $ref")
+		}
+		resp := suite.GetReference(ref)
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+		suite.Equal(userID, suite.GetString("id", suite.ReadAllToMap(resp)))
+	})
+
+	suite.Run("UserGroups", func() {
+		// The groups of a user is a list of the groups to which the user belongs, either through direct membership,
+		// through nested groups, or dynamically calculated.
+		user := suite.ReadAllToMap(suite.GetOk(fmt.Sprintf("/Users/%s", userID)))
+		suite.Should().True(hasValue(user["groups"], groupID), "the groups of the user do not contain the group")
+	})
+
+	suite.Run("UserGroupsReadOnly", func() {
+		// Since the groups of a user have a mutability of "readOnly", group membership changes MUST be applied via
+		// the group resource. They are either ignored or rejected when given for the user.
+		resp := suite.Post("/Users", suite.Body(map[string]interface{}{
+			"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+			"userName": suite.Namespaced("readonly"),
+			"groups": []map[string]interface{}{
+				{"value": groupID},
+			},
+		}))
+		if resp.StatusCode == http.StatusBadRequest {
+			return
+		}
+		suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+		id := suite.GetString("id", suite.ReadAllToMap(resp))
+		suite.Nil(suite.member(groupID, id), "the user became a member through its groups")
+	})
+
+	suite.Run("NestedGroups", func() {
+		// Groups can be members of groups. A service provider that does not support nested groups rejects them.
+		resp := suite.Post("/Groups", suite.groupBody(suite.Namespaced("nested"), map[string]interface{}{
+			"value": groupID,
+			"type":  "Group",
+		}))
+		if resp.StatusCode != http.StatusCreated {
+			suite.Equal(http.StatusBadRequest, resp.StatusCode)
+			return
+		}
+		id := suite.GetString("id", suite.ReadAllToMap(resp))
+		member := suite.member(id, groupID)
+		suite.Require().NotNil(member, "the group is not a member of the nested group")
+		if memberType, ok := member["type"]; ok {
+			suite.Equal("Group", memberType)
+		}
+	})
+
+	suite.Run("DeleteRemovesMembership", func() {
+		// A deleted user is no longer returned, neither as a member of the groups it belonged to.
+		resp := suite.Delete(fmt.Sprintf("/Users/%s", userID))
+		suite.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		suite.Should().Nil(suite.member(groupID, userID), "the deleted user is still a member of the group")
+	})
+}
+
+// createUser creates a user with the given (namespaced) user name and returns its identifier.
+func (suite *SCIMTestSuite) createUser(userName string) string {
+	resp := suite.Post("/Users", suite.userBody(suite.Namespaced(userName)))
+	suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	return suite.GetString("id", suite.ReadAllToMap(resp))
+}
+
+// createGroup creates a group with the given (namespaced) display name and members and returns its identifier.
+func (suite *SCIMTestSuite) createGroup(displayName string, members ...map[string]interface{}) string {
+	resp := suite.Post("/Groups", suite.groupBody(suite.Namespaced(displayName), members...))
+	suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	return suite.GetString("id", suite.ReadAllToMap(resp))
+}
+
+func (suite *SCIMTestSuite) groupBody(displayName string, members ...map[string]interface{}) *bytes.Reader {
+	return suite.Body(util.GroupBody(displayName, members...))
+}
+
+// member returns the member of the group with the given value, or nil if there is none.
+func (suite *SCIMTestSuite) member(groupID, value string) map[string]interface{} {
+	for _, m := range suite.members(groupID) {
+		if member, ok := m.(map[string]interface{}); ok && member["value"] == value {
+			return member
+		}
+	}
+	return nil
+}
+
+// hasValue returns whether one of the values of the given multi-valued attribute has the given value.
+func hasValue(attribute interface{}, value string) bool {
+	values, _ := attribute.([]interface{})
+	for _, v := range values {
+		if v, ok := v.(map[string]interface{}); ok && v["value"] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -189,7 +189,7 @@ func (s *TestSuite) TestUsers() {
 	s.Run("Get User Filters", func() {
 		var (
 			// NOTE: typo: "/Users/?filter=DisplayName+eq+%22BobIsAmazing%22"
-			filter    = url.Values{"filter": []string{"displayName eq \"di-wu\""}}
+			filter    = url.Values{"filter": []string{fmt.Sprintf("displayName eq \"%s\"", s.Namespaced("di-wu"))}}
 			resp      = s.Get(fmt.Sprintf("/Users?%s", filter.Encode()))
 			mapData   = s.ReadAllToMap(resp)
 			resources = s.GetSlice("Resources", mapData)
@@ -213,7 +213,7 @@ func (s *TestSuite) TestUsers() {
 				{
 					"op":    "replace",
 					"path":  "userName",
-					"value": "quint.d",
+					"value": s.Namespaced("quint.d"),
 				},
 			},
 		})
@@ -242,7 +242,7 @@ func (s *TestSuite) TestUsers() {
 		})
 
 		s.Run("Username is changed", func() {
-			s.Require().Equal("quint.d", userName)
+			s.Require().Equal(s.Namespaced("quint.d"), userName)
 		})
 	})
 
@@ -608,7 +608,7 @@ func (s *TestSuite) TestComplexAttributes() {
 						value = s.GetString("value", email)
 					)
 
-					if value == "complex1@elimity.com" {
+					if value == s.Namespaced("complex1@elimity.com") {
 						hit = true
 						break
 					}
@@ -620,7 +620,7 @@ func (s *TestSuite) TestComplexAttributes() {
 
 	s.Run("Get user via attribute filter", func() {
 		var (
-			filter = url.Values{"attributes": []string{"emails[value eq \"complex1@elimity.com\"]"}}
+			filter = url.Values{"attributes": []string{fmt.Sprintf("emails[value eq \"%s\"]", s.Namespaced("complex1@elimity.com"))}}
 			resp   = s.Get(fmt.Sprintf("/Users?%s", filter.Encode()))
 		)
 		s.Run("Status code is 200", func() {
@@ -645,7 +645,7 @@ func (s *TestSuite) TestComplexAttributes() {
 						value = s.GetString("value", email)
 					)
 
-					if value == "complex1@elimity.com" {
+					if value == s.Namespaced("complex1@elimity.com") {
 						hit = true
 						break
 					}
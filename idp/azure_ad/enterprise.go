@@ -0,0 +1,156 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// SOURCE: https://tools.ietf.org/html/rfc7643#section-4.3
+//		   https://learn.microsoft.com/en-us/entra/identity/app-provisioning/use-scim-to-provision-users-and-groups
+
+// The attributes of the enterprise extension are returned as they were sent, except for the manager's display name.
+func (s *TestSuite) TestEnterpriseUserRoundTrip() {
+	managerID, manager := s.createUser()
+	var (
+		ref       = s.NewRequest(http.MethodGet, fmt.Sprintf("/Users/%s", managerID), nil).URL.String()
+		extension = map[string]interface{}{
+			"employeeNumber": s.randomName(),
+			"costCenter":     s.randomName(),
+			"organization":   s.randomName(),
+			"division":       s.randomName(),
+			"department":     s.randomName(),
+			"manager": map[string]interface{}{
+				"value":       managerID,
+				"$ref":        ref,
+				"displayName": s.randomName(),
+			},
+		}
+		user = s.createUserBody("enterprise", "enterprise")
+	)
+	user["schemas"] = []string{"urn:ietf:params:scim:schemas:core:2.0:User", enterpriseUser}
+	user[enterpriseUser] = extension
+
+	resp := s.Post("/Users", s.Body(user))
+	s.Run("Status code is 201", func() {
+		s.StatusCreated(resp.StatusCode)
+	})
+	id := s.GetString("id", s.ReadAllToMap(resp))
+
+	entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+	s.Run("Schemas contain extension", func() {
+		s.Contains(entity["schemas"], enterpriseUser)
+	})
+
+	returned := s.GetMap(enterpriseUser, entity)
+	for _, name := range []string{"employeeNumber", "costCenter", "organization", "division", "department"} {
+		name := name
+		s.Run(fmt.Sprintf("%s matches", name), func() {
+			s.Equal(extension[name], returned[name])
+		})
+	}
+
+	returnedManager := s.GetMap("manager", returned)
+	s.Run("manager.value matches", func() {
+		s.Equal(managerID, returnedManager["value"])
+	})
+
+	s.Run("manager.$ref matches", func() {
+		if returnedManager["$ref"] == nil {
+			s.T().Skip("manager.$ref is not returned")
+		}
+		s.Equal(ref, returnedManager["$ref"])
+	})
+
+	s.Run("manager.displayName is read only", func() {
+		if displayName := returnedManager["displayName"]; displayName != nil {
+			s.Equal(manager["displayName"], displayName)
+		}
+	})
+}
+
+// Attribute names are case insensitive, the Postman collection sends "Department" and "Manager" (RFC 7643 2.1).
+func (s *TestSuite) TestEnterpriseUserAttributeNames() {
+	resp := s.Post("/Users", s.Body(s.createEnterpriseUserBody("capitalised", "capitalised")))
+	s.Run("Status code is 201", func() {
+		s.StatusCreated(resp.StatusCode)
+	})
+
+	var (
+		entity    = s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", s.GetString("id", s.ReadAllToMap(resp)))))
+		extension = s.GetMap(enterpriseUser, entity)
+	)
+	s.Run("Schemas contain extension", func() {
+		s.Contains(entity["schemas"], enterpriseUser)
+	})
+
+	s.Run("Department matches", func() {
+		s.Equal("Engineering", lookup(extension, "department"))
+	})
+
+	s.Run("Manager matches", func() {
+		manager, _ := lookup(extension, "manager").(map[string]interface{})
+		s.Equal("M.", lookup(manager, "value"))
+	})
+}
+
+// The enterprise extension is patched with a URN path, or without path as a nested object.
+func (s *TestSuite) TestPatchEnterpriseUser() {
+	id, _ := s.createUser()
+
+	s.Run("URN path", func() {
+		department := s.randomName()
+		resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+			"op":    "Replace",
+			"path":  enterpriseUser + ":department",
+			"value": department,
+		})))
+		s.Run("Status code is 200 or 204", func() {
+			s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+		})
+
+		s.Run("Department matches", func() {
+			entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+			s.Equal(department, s.GetMap(enterpriseUser, entity)["department"])
+		})
+	})
+
+	s.Run("Nested object", func() {
+		costCenter, division := s.randomName(), s.randomName()
+		resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+			"op": "Replace",
+			"value": map[string]interface{}{
+				enterpriseUser: map[string]interface{}{
+					"costCenter": costCenter,
+					"division":   division,
+				},
+			},
+		})))
+		s.Run("Status code is 200 or 204", func() {
+			s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+		})
+
+		entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+		extension := s.GetMap(enterpriseUser, entity)
+		s.Run("Attributes match", func() {
+			s.Equal(costCenter, extension["costCenter"])
+			s.Equal(division, extension["division"])
+		})
+
+		s.Run("Other attributes are unchanged", func() {
+			s.NotNil(extension["department"])
+		})
+	})
+}
+
+// lookup returns the value of the given attribute name, case insensitive.
+func lookup(m map[string]interface{}, name string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
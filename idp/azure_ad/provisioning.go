@@ -0,0 +1,247 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// SOURCE: https://learn.microsoft.com/en-us/entra/identity/app-provisioning/use-scim-to-provision-users-and-groups
+//		   https://learn.microsoft.com/en-us/entra/identity/app-provisioning/application-provisioning-config-problem-scim-compatibility
+
+const enterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+// Azure AD matches users by their external id, an empty list response means that the user does not exist yet.
+func (s *TestSuite) TestGetUserByExternalID() {
+	id, user := s.createUser()
+
+	s.Run("Existing", func() {
+		resources := s.filterUsers(fmt.Sprintf("externalId eq \"%s\"", user["externalId"]))
+
+		s.Run("Single result", func() {
+			s.Require().Len(resources, 1)
+		})
+
+		s.Run("Id matches", func() {
+			s.Require().NotEmpty(resources)
+			s.Equal(id, s.IsMap(resources[0])["id"])
+		})
+	})
+
+	s.Run("Zero results", func() {
+		resources := s.filterUsers(fmt.Sprintf("externalId eq \"%s\"", objectID()))
+		s.Empty(resources)
+	})
+}
+
+// Azure AD capitalises the operations of PATCH requests, e.g. "Replace".
+func (s *TestSuite) TestPatchCapitalisedOperations() {
+	id, _ := s.createUser()
+	displayName, title := s.randomName(), s.randomName()
+	resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(
+		map[string]interface{}{
+			"op":    "Replace",
+			"path":  "displayName",
+			"value": displayName,
+		},
+		map[string]interface{}{
+			"op":    "Add",
+			"path":  "title",
+			"value": title,
+		},
+		map[string]interface{}{
+			"op":   "Remove",
+			"path": "nickName",
+		},
+	)))
+	s.Run("Status code is 200 or 204", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+	s.Run("Replaced", func() {
+		s.Equal(displayName, entity["displayName"])
+	})
+
+	s.Run("Added", func() {
+		s.Equal(title, entity["title"])
+	})
+
+	s.Run("Removed", func() {
+		s.Nil(entity["nickName"])
+	})
+}
+
+// Azure AD sends boolean values as strings, e.g. "False".
+func (s *TestSuite) TestPatchBooleanStrings() {
+	id, _ := s.createUser()
+
+	for _, active := range []bool{false, true} {
+		active := active
+		value := "False"
+		if active {
+			value = "True"
+		}
+
+		s.Run(value, func() {
+			resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+				"op":    "Replace",
+				"path":  "active",
+				"value": value,
+			})))
+			s.Run("Status code is 200 or 204", func() {
+				s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+			})
+
+			s.Run("Active is a boolean", func() {
+				entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+				s.Equal(active, entity["active"])
+			})
+		})
+	}
+}
+
+// Azure AD sets the manager of a user to its id, instead of a complex value.
+func (s *TestSuite) TestPatchManager() {
+	id, _ := s.createUser()
+	managerID, _ := s.createUser()
+	path := enterpriseUser + ":manager"
+
+	s.Run("Add", func() {
+		resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+			"op":    "Add",
+			"path":  path,
+			"value": managerID,
+		})))
+		s.Run("Status code is 200 or 204", func() {
+			s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+		})
+
+		s.Run("Manager matches", func() {
+			entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+			extension, _ := entity[enterpriseUser].(map[string]interface{})
+			manager, _ := extension["manager"].(map[string]interface{})
+			s.Equal(managerID, manager["value"])
+		})
+	})
+
+	s.Run("Remove", func() {
+		resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+			"op":   "Remove",
+			"path": path,
+		})))
+		s.Run("Status code is 200 or 204", func() {
+			s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+		})
+
+		s.Run("Manager is removed", func() {
+			entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+			extension, _ := entity[enterpriseUser].(map[string]interface{})
+			s.Nil(extension["manager"])
+		})
+	})
+}
+
+// Azure AD updates multi-valued attributes with a value path, e.g. the work email.
+func (s *TestSuite) TestPatchWorkEmail() {
+	id, user := s.createUser()
+	email := s.randomEmail()
+	resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op":    "Replace",
+		"path":  "emails[type eq \"work\"].value",
+		"value": email,
+	})))
+	s.Run("Status code is 200 or 204", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	var (
+		entity = s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+		emails = make(map[string]interface{})
+	)
+	for _, e := range s.GetSlice("emails", entity) {
+		e := s.IsMap(e)
+		emails[s.GetString("type", e)] = e["value"]
+	}
+
+	s.Run("Work email is replaced", func() {
+		s.Equal(email, emails["work"])
+	})
+
+	s.Run("Other emails are unchanged", func() {
+		s.Equal(user["emails"].([]map[string]interface{})[1]["value"], emails["home"])
+	})
+}
+
+// Azure AD disables users instead of deleting them, they should still be found by their external id.
+func (s *TestSuite) TestSoftDelete() {
+	id, user := s.createUser()
+	resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op":    "Replace",
+		"path":  "active",
+		"value": false,
+	})))
+	s.Run("Status code is 200 or 204", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	s.Run("User is inactive", func() {
+		resp := s.Get(fmt.Sprintf("/Users/%s", id))
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Equal(false, s.ReadAllToMap(resp)["active"])
+	})
+
+	s.Run("User is found", func() {
+		resources := s.filterUsers(fmt.Sprintf("externalId eq \"%s\"", user["externalId"]))
+		s.Require().Len(resources, 1)
+		s.Equal(id, s.IsMap(resources[0])["id"])
+	})
+}
+
+// createUser creates a user like the provisioning service does and returns its id and the body of the request.
+func (s *TestSuite) createUser() (string, map[string]interface{}) {
+	userName, givenName, familyName := s.randomEmail(), s.randomName(), s.randomName()
+	user := map[string]interface{}{
+		"schemas":    []string{"urn:ietf:params:scim:schemas:core:2.0:User", enterpriseUser},
+		"externalId": objectID(),
+		"userName":   userName,
+		"active":     true,
+		"nickName":   givenName,
+		"name": map[string]interface{}{
+			"formatted":  fmt.Sprintf("%s %s", givenName, familyName),
+			"familyName": familyName,
+			"givenName":  givenName,
+		},
+		"emails": []map[string]interface{}{
+			{
+				"primary": true,
+				"type":    "work",
+				"value":   userName,
+			},
+			{
+				"primary": false,
+				"type":    "home",
+				"value":   s.randomEmail(),
+			},
+		},
+		enterpriseUser: map[string]interface{}{
+			"department": s.randomName(),
+		},
+	}
+	resp := s.Post("/Users", s.Body(user))
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	return s.GetString("id", s.ReadAllToMap(resp)), user
+}
+
+// filterUsers returns the users that match the given filter.
+func (s *TestSuite) filterUsers(filter string) []interface{} {
+	resp := s.GetOk(fmt.Sprintf("/Users?%s", url.Values{"filter": []string{filter}}.Encode()))
+	mapData := s.ReadAllToMap(resp)
+	if resources, ok := mapData["Resources"]; !ok || resources == nil {
+		// the resources can be left out if there are none.
+		return nil
+	}
+	return s.GetSlice("Resources", mapData)
+}
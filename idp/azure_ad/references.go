@@ -0,0 +1,66 @@
+package azure
+
+import "github.com/di-wu/scim-test-suite/report"
+
+const (
+	spec = "Azure AD SCIM Postman Collection"
+	// provisioning is the spec of the requests that the Azure AD provisioning service sends, with its known SCIM
+	// compatibility issues.
+	provisioning = "Azure AD SCIM Provisioning"
+)
+
+// References links the tests of the TestSuite to the requests of the Azure AD SCIM Postman collection, or of the
+// provisioning service. The tests within a request (e.g. "Status code is 200") inherit the reference of the request.
+var References = report.References{
+	"TestEndpoints/Get empty Users":           {Spec: spec, Section: "Get empty Users"},
+	"TestEndpoints/Get empty Groups":          {Spec: spec, Section: "Get empty Groups"},
+	"TestEndpoints/Get ResourceTypes":         {Spec: spec, Section: "Get ResourceTypes"},
+	"TestEndpoints/Get ServiceProviderConfig": {Spec: spec, Section: "Get ServiceProviderConfig"},
+	"TestEndpoints/Get Schemas":               {Spec: spec, Section: "Get Schemas"},
+
+	"TestUsers/Post User":               {Spec: spec, Section: "Post User"},
+	"TestUsers/Post EnterpriseUser":     {Spec: spec, Section: "Post EnterpriseUser"},
+	"TestUsers/Get user1":               {Spec: spec, Section: "Get user1"},
+	"TestUsers/Get user2":               {Spec: spec, Section: "Get user2"},
+	"TestUsers/Get User Attributes":     {Spec: spec, Section: "Get User Attributes"},
+	"TestUsers/Get User Filters":        {Spec: spec, Section: "Get User Filters"},
+	"TestUsers/Patch user1":             {Spec: spec, Section: "Patch user1"},
+	"TestUsers/Get user1 check Patch":   {Spec: spec, Section: "Get user1 check Patch"},
+	"TestUsers/Replace user2":           {Spec: spec, Section: "Replace user2"},
+	"TestUsers/Get user2 check Replace": {Spec: spec, Section: "Get user2 check Replace"},
+	"TestUsers/Delete user1":            {Spec: spec, Section: "Delete user1"},
+	"TestUsers/Delete user2":            {Spec: spec, Section: "Delete user2"},
+
+	"TestGroups/Create empty group":           {Spec: spec, Section: "Create empty group"},
+	"TestGroups/Create users":                 {Spec: spec, Section: "Create users"},
+	"TestGroups/Create filled group2":         {Spec: spec, Section: "Create filled group2"},
+	"TestGroups/Get Groups":                   {Spec: spec, Section: "Get Groups"},
+	"TestGroups/Create  group3":               {Spec: spec, Section: "Create group3"},
+	"TestGroups/Replace group3":               {Spec: spec, Section: "Replace group3"},
+	"TestGroups/Validate group3":              {Spec: spec, Section: "Validate group3"},
+	"TestGroups/Patch add user4 to group1":    {Spec: spec, Section: "Patch add user4 to group1"},
+	"TestGroups/Patch remove user4 to group1": {Spec: spec, Section: "Patch remove user4 to group1"},
+	"TestGroups/Get group1 by id":             {Spec: spec, Section: "Get group1 by id"},
+	"TestGroups/Patch remove all users":       {Spec: spec, Section: "Patch remove all users"},
+	"TestGroups/Delete groups":                {Spec: spec, Section: "Delete groups"},
+	"TestGroups/Delete users":                 {Spec: spec, Section: "Delete users"},
+
+	"TestComplexAttributes/Create user1":                  {Spec: spec, Section: "Create user1"},
+	"TestComplexAttributes/Create user2":                  {Spec: spec, Section: "Create user2"},
+	"TestComplexAttributes/Get user attributes":           {Spec: spec, Section: "Get user attributes"},
+	"TestComplexAttributes/Get user via attribute filter": {Spec: spec, Section: "Get user via attribute filter"},
+	"TestComplexAttributes/Delete user1":                  {Spec: spec, Section: "Delete user1"},
+	"TestComplexAttributes/Delete user2":                  {Spec: spec, Section: "Delete user2"},
+
+	"TestGetUserByExternalID/Existing":     {Spec: provisioning, Section: "Get User by query"},
+	"TestGetUserByExternalID/Zero results": {Spec: provisioning, Section: "Get User by query - Zero results"},
+	"TestPatchCapitalisedOperations":       {Spec: provisioning, Section: "Update User [Single-valued properties]"},
+	"TestPatchBooleanStrings":              {Spec: provisioning, Section: "Known issues: boolean values as strings"},
+	"TestPatchManager":                     {Spec: provisioning, Section: "Known issues: manager attribute"},
+	"TestPatchWorkEmail":                   {Spec: provisioning, Section: "Update User [Multi-valued properties]"},
+	"TestSoftDelete":                       {Spec: provisioning, Section: "Disable User"},
+
+	"TestEnterpriseUserRoundTrip":      {Spec: "RFC7643", Section: "4.3", Level: report.MUST},
+	"TestEnterpriseUserAttributeNames": {Spec: "RFC7643", Section: "2.1", Level: report.MUST},
+	"TestPatchEnterpriseUser":          {Spec: "RFC7644", Section: "3.5.2", Level: report.MUST},
+}
@@ -2,6 +2,7 @@ package azure
 
 import (
 	"fmt"
+	"github.com/di-wu/regen"
 	"github.com/di-wu/scim-test-suite/util"
 )
 
@@ -9,7 +10,27 @@ type TestSuite struct {
 	util.Suite
 }
 
+// randomName returns a random name, prefixed with the namespace of the run.
+func (s *TestSuite) randomName() string {
+	gen, _ := regen.New(`^[a-zA-Z0-9]{8,16}`)
+	return s.Namespaced(gen.Generate())
+}
+
+// randomEmail returns a random email address, prefixed with the namespace of the run.
+func (s *TestSuite) randomEmail() string {
+	gen, _ := regen.New(`^[a-z0-9]{8,16}@[a-z0-9]{4,8}\.[a-z]{2,4}$`)
+	return s.Namespaced(gen.Generate())
+}
+
+// objectID returns a random object id, Azure AD uses the object id of a user as its external id.
+func objectID() string {
+	gen, _ := regen.New(`\b[0-9a-f]{8}\b-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-\b[0-9a-f]{12}\b`)
+	return gen.Generate()
+}
+
+// createUserBody returns the body of a new user, the given names are prefixed with the namespace of the run.
 func (s *TestSuite) createUserBody(userName, displayName string) map[string]interface{} {
+	userName, displayName = s.Namespaced(userName), s.Namespaced(displayName)
 	return map[string]interface{}{
 		"userName":    userName,
 		"active":      true,
@@ -40,7 +61,10 @@ func (s *TestSuite) createUserBody(userName, displayName string) map[string]inte
 	}
 }
 
+// createEnterpriseUserBody returns the body of a new enterprise user, the given names are prefixed with the namespace
+// of the run.
 func (s *TestSuite) createEnterpriseUserBody(userName, displayName string) map[string]interface{} {
+	userName, displayName = s.Namespaced(userName), s.Namespaced(displayName)
 	return map[string]interface{}{
 		"userName":    userName,
 		"active":      true,
@@ -75,6 +99,8 @@ func (s *TestSuite) createEnterpriseUserBody(userName, displayName string) map[s
 	}
 }
 
+// createGroup returns the body of a new group with the given members, the display name is prefixed with the namespace
+// of the run.
 func (s *TestSuite) createGroup(displayName string, userIDs ...string) map[string]interface{} {
 	var members []map[string]interface{}
 	for _, id := range userIDs {
@@ -85,7 +111,7 @@ func (s *TestSuite) createGroup(displayName string, userIDs ...string) map[strin
 
 	return map[string]interface{}{
 		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
-		"displayName": displayName,
+		"displayName": s.Namespaced(displayName),
 		"members":     members,
 	}
 }
@@ -0,0 +1,295 @@
+package okta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// SOURCE: https://developer.okta.com/docs/reference/scim/scim-20/#scim-group-operations
+
+// GroupPushSuite tests the group operations of Okta's Group Push.
+type GroupPushSuite struct {
+	util.Suite
+}
+
+// oktaUser is a user as Okta pushes it, the member of a pushed group.
+type oktaUser struct {
+	id, userName string
+}
+
+// TestGroupPush pushes a group, updates its members and name and deletes it again.
+func (s *GroupPushSuite) TestGroupPush() {
+	var (
+		displayName = s.Namespaced("Okta Group Push")
+		users       = []oktaUser{s.createUser("push.member1"), s.createUser("push.member2")}
+		id          string
+	)
+
+	s.Run("FindByDisplayName", func() {
+		s.testFindByDisplayName(displayName, 0)
+	})
+
+	s.Run("CreateGroup", func() {
+		id = s.testCreateGroup(displayName)
+	})
+	s.Require().NotEmpty(id, "the group was not created")
+
+	s.Run("GetGroup", func() {
+		s.testGetGroup(id, displayName)
+	})
+
+	s.Run("FindCreatedGroup", func() {
+		s.testFindByDisplayName(displayName, 1)
+	})
+
+	s.Run("AddMembers", func() {
+		s.testAddMembers(id, users...)
+	})
+
+	s.Run("RemoveMember", func() {
+		s.testRemoveMember(id, users[0], users[1:]...)
+	})
+
+	s.Run("RenameGroup", func() {
+		displayName = s.Namespaced("Okta Group Push Renamed")
+		s.testRenameGroup(id, displayName)
+	})
+
+	s.Run("ReplaceGroup", func() {
+		s.testReplaceGroup(id, displayName, users[0])
+	})
+
+	s.Run("DeleteGroup", func() {
+		s.testDeleteGroup(id)
+	})
+}
+
+// GET /Groups with a displayName filter, to link the group to an existing one.
+func (s *GroupPushSuite) testFindByDisplayName(displayName string, totalResults int) {
+	query := url.Values{
+		"filter":     []string{fmt.Sprintf("displayName eq \"%s\"", displayName)},
+		"startIndex": []string{"1"},
+		"count":      []string{"100"},
+	}
+	resp := s.Get(fmt.Sprintf("/Groups?%s", query.Encode()))
+
+	s.Run("StatusCode", func() {
+		s.StatusOK(resp.StatusCode)
+	})
+
+	mapData := s.ReadAllToMap(resp)
+
+	s.Run("ContainsSchema", func() {
+		s.Contains(mapData["schemas"], "urn:ietf:params:scim:api:messages:2.0:ListResponse")
+	})
+
+	s.Run("TotalResults", func() {
+		s.Equal(json.Number(fmt.Sprint(totalResults)), mapData["totalResults"])
+	})
+}
+
+// POST /Groups with an empty list of members, they are pushed afterwards.
+func (s *GroupPushSuite) testCreateGroup(displayName string) string {
+	resp := s.Post("/Groups", s.Body(map[string]interface{}{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"displayName": displayName,
+		"members":     []interface{}{},
+	}))
+
+	s.Run("StatusCode", func() {
+		s.StatusCreated(resp.StatusCode)
+	})
+
+	entity := s.ReadAllToMap(resp)
+
+	s.Run("IDNotEmpty", func() {
+		s.NotEmpty(entity["id"])
+	})
+
+	s.Run("DisplayNameMatches", func() {
+		s.Equal(displayName, entity["displayName"])
+	})
+
+	s.Run("ContainsSchema", func() {
+		s.Contains(entity["schemas"], "urn:ietf:params:scim:schemas:core:2.0:Group")
+	})
+
+	id, _ := entity["id"].(string)
+	return id
+}
+
+// GET /Groups/{id} without the members, Okta does not need them to push a group.
+func (s *GroupPushSuite) testGetGroup(id, displayName string) {
+	resp := s.Get(fmt.Sprintf("/Groups/%s?excludedAttributes=members", id))
+
+	s.Run("StatusCode", func() {
+		s.StatusOK(resp.StatusCode)
+	})
+
+	entity := s.ReadAllToMap(resp)
+
+	s.Run("IDsMatch", func() {
+		s.Equal(id, entity["id"])
+	})
+
+	s.Run("DisplayNameMatches", func() {
+		s.Equal(displayName, entity["displayName"])
+	})
+}
+
+// PATCH /Groups/{id} that adds the members.
+func (s *GroupPushSuite) testAddMembers(id string, users ...oktaUser) {
+	members := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		members[i] = map[string]interface{}{
+			"value":   user.id,
+			"display": user.userName,
+		}
+	}
+	resp := s.Patch(fmt.Sprintf("/Groups/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op":    "add",
+		"path":  "members",
+		"value": members,
+	})))
+
+	s.Run("StatusCode", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	s.Run("Members", func() {
+		s.ElementsMatch(ids(users), s.members(id))
+	})
+}
+
+// PATCH /Groups/{id} that removes a single member with a value filter.
+func (s *GroupPushSuite) testRemoveMember(id string, user oktaUser, remaining ...oktaUser) {
+	resp := s.Patch(fmt.Sprintf("/Groups/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op":   "remove",
+		"path": fmt.Sprintf("members[value eq \"%s\"]", user.id),
+	})))
+
+	s.Run("StatusCode", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	s.Run("Members", func() {
+		s.ElementsMatch(ids(remaining), s.members(id))
+	})
+}
+
+// PATCH /Groups/{id} without a path that replaces the display name.
+func (s *GroupPushSuite) testRenameGroup(id, displayName string) {
+	resp := s.Patch(fmt.Sprintf("/Groups/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op": "replace",
+		"value": map[string]interface{}{
+			"id":          id,
+			"displayName": displayName,
+		},
+	})))
+
+	s.Run("StatusCode", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	s.Run("DisplayNameMatches", func() {
+		entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Groups/%s?excludedAttributes=members", id)))
+		s.Equal(displayName, entity["displayName"])
+	})
+}
+
+// PUT /Groups/{id} with all the members of the group.
+func (s *GroupPushSuite) testReplaceGroup(id, displayName string, users ...oktaUser) {
+	members := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		members[i] = map[string]interface{}{
+			"value":   user.id,
+			"display": user.userName,
+		}
+	}
+	resp := s.Put(fmt.Sprintf("/Groups/%s", id), s.Body(map[string]interface{}{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"id":          id,
+		"displayName": displayName,
+		"members":     members,
+	}))
+
+	s.Run("StatusCode", func() {
+		s.StatusOK(resp.StatusCode)
+	})
+
+	entity := s.ReadAllToMap(resp)
+
+	s.Run("DisplayNameMatches", func() {
+		s.Equal(displayName, entity["displayName"])
+	})
+
+	s.Run("Members", func() {
+		s.ElementsMatch(ids(users), s.members(id))
+	})
+}
+
+// DELETE /Groups/{id} once the group is no longer pushed.
+func (s *GroupPushSuite) testDeleteGroup(id string) {
+	resp := s.Delete(fmt.Sprintf("/Groups/%s", id))
+
+	s.Run("StatusCode", func() {
+		s.StatusNoContent(resp.StatusCode)
+	})
+
+	s.Run("NotFound", func() {
+		s.StatusNotFound(s.Get(fmt.Sprintf("/Groups/%s", id)).StatusCode)
+	})
+}
+
+// createUser creates a user to push as a member.
+func (s *GroupPushSuite) createUser(name string) oktaUser {
+	userName := s.Namespaced(name) + "@okta.local"
+	resp := s.Post("/Users", s.Body(map[string]interface{}{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": userName,
+		"name": map[string]interface{}{
+			"givenName":  "Push",
+			"familyName": name,
+		},
+		"emails": []map[string]interface{}{
+			{
+				"primary": true,
+				"value":   userName,
+				"type":    "work",
+			},
+		},
+		"displayName": fmt.Sprintf("Push %s", name),
+		"active":      true,
+	}))
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	return oktaUser{
+		id:       s.GetString("id", s.ReadAllToMap(resp)),
+		userName: userName,
+	}
+}
+
+// members returns the identifiers of the members of the group.
+func (s *GroupPushSuite) members(id string) []string {
+	entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Groups/%s?attributes=members", id)))
+	values, _ := entity["members"].([]interface{})
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if member, ok := v.(map[string]interface{}); ok {
+			id, _ := member["value"].(string)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func ids(users []oktaUser) []string {
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.id
+	}
+	return ids
+}
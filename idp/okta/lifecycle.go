@@ -0,0 +1,187 @@
+package okta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// SOURCE: https://developer.okta.com/docs/reference/scim/scim-20/#scim-user-operations
+
+// Okta deactivates a user with a PATCH without path, users are never deleted.
+func (s *TestSuite) TestDeactivateUser() {
+	id, _ := s.createUser()
+
+	s.Run("Deactivate", func() {
+		s.testSetActive(id, false)
+	})
+
+	s.Run("Reactivate", func() {
+		s.testSetActive(id, true)
+	})
+}
+
+// PATCH /Users/{id} that replaces the active attribute.
+func (s *TestSuite) testSetActive(id string, active bool) {
+	resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op": "replace",
+		"value": map[string]interface{}{
+			"active": active,
+		},
+	})))
+
+	// Assertion 0
+	s.Run("StatusCode", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	// Assertion 1
+	s.Run("ActiveMatches", func() {
+		entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+		s.Equal(active, entity["active"])
+	})
+}
+
+// Okta updates the profile of a user with a PUT of the whole user.
+func (s *TestSuite) TestUpdateUserWithPut() {
+	id, user := s.createUser()
+	givenName, familyName := s.RandomName(), s.RandomName()
+	user["id"] = id
+	user["name"] = map[string]interface{}{
+		"givenName":  givenName,
+		"familyName": familyName,
+	}
+	user["displayName"] = fmt.Sprintf("%s %s", givenName, familyName)
+	user["groups"] = []interface{}{}
+	resp := s.Put(fmt.Sprintf("/Users/%s", id), s.Body(user))
+
+	// Assertion 0
+	s.Run("StatusCode", func() {
+		s.StatusOK(resp.StatusCode)
+	})
+
+	entity := s.ReadAllToMap(resp)
+
+	// Assertion 1
+	s.Run("IDsMatch", func() {
+		s.Equal(id, entity["id"])
+	})
+
+	name := s.GetMap("name", entity)
+
+	// Assertion 2
+	s.Run("FamilyNameMatches", func() {
+		s.Equal(familyName, name["familyName"])
+	})
+
+	// Assertion 3
+	s.Run("GivenNameMatches", func() {
+		s.Equal(givenName, name["givenName"])
+	})
+
+	// Assertion 4
+	s.Run("VerifyUpdate", func() {
+		entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+		s.Equal(user["displayName"], entity["displayName"])
+	})
+}
+
+// Okta pushes the password of a user with a PATCH without path.
+func (s *TestSuite) TestPushPassword() {
+	config := s.ReadAllToMap(s.GetOk("/ServiceProviderConfig"))
+	if !s.GetBool("supported", s.GetMap("changePassword", config)) {
+		s.T().Skip("changing passwords is not supported")
+	}
+
+	id, _ := s.createUser()
+	resp := s.Patch(fmt.Sprintf("/Users/%s", id), s.Body(util.PatchBody(map[string]interface{}{
+		"op": "replace",
+		"value": map[string]interface{}{
+			"password": s.RandomName(),
+		},
+	})))
+
+	// Assertion 0
+	s.Run("StatusCode", func() {
+		s.Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+	})
+
+	// Assertion 1
+	s.Run("PasswordNotReturned", func() {
+		entity := s.ReadAllToMap(s.GetOk(fmt.Sprintf("/Users/%s", id)))
+		s.Nil(entity["password"])
+	})
+}
+
+// Okta reactivates a user that is assigned again, instead of creating a new one.
+func (s *TestSuite) TestReprovisionUser() {
+	id, user := s.createUser()
+	s.testSetActive(id, false)
+
+	filter := url.Values{
+		"filter":     []string{fmt.Sprintf("userName eq \"%s\"", user["userName"])},
+		"startIndex": []string{"1"},
+		"count":      []string{"100"},
+	}
+	resp := s.Get(fmt.Sprintf("/Users?%s", filter.Encode()))
+
+	// Assertion 0
+	s.Run("StatusCode", func() {
+		s.StatusOK(resp.StatusCode)
+	})
+
+	mapData := s.ReadAllToMap(resp)
+
+	// Assertion 1
+	s.Run("TotalResultsIsNumber1", func() {
+		s.Equal(json.Number("1"), mapData["totalResults"])
+	})
+
+	var (
+		resources = s.GetSlice("Resources", mapData)
+		entity    = s.IsMap(resources[0])
+	)
+
+	// Assertion 2
+	s.Run("IDsMatch", func() {
+		s.Equal(id, entity["id"])
+	})
+
+	// Assertion 3
+	s.Run("ActiveFalse", func() {
+		s.Equal(false, entity["active"])
+	})
+
+	// Next Tests
+	s.Run("Reactivate", func() {
+		s.testSetActive(id, true)
+	})
+}
+
+// createUser creates a user like Okta does and returns its id and the body of the request.
+func (s *TestSuite) createUser() (string, map[string]interface{}) {
+	userName, givenName, familyName := s.RandomEmail(), s.RandomName(), s.RandomName()
+	user := map[string]interface{}{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": userName,
+		"name": map[string]interface{}{
+			"givenName":  givenName,
+			"familyName": familyName,
+		},
+		"emails": []map[string]interface{}{
+			{
+				"primary": true,
+				"value":   userName,
+				"type":    "work",
+			},
+		},
+		"displayName": fmt.Sprintf("%s %s", givenName, familyName),
+		"active":      true,
+	}
+	resp := s.Post("/Users", s.Body(user))
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	return s.GetString("id", s.ReadAllToMap(resp)), user
+}
@@ -368,6 +368,6 @@ func (s *TestSuite) TestGetGroups() {
 
 	// Assertion 1
 	s.Run("ResponseTime", func() {
-		s.LessOrEqual(d.Milliseconds(), int64(600))
+		s.Should().LessOrEqual(d.Milliseconds(), int64(600))
 	})
 }
@@ -0,0 +1,90 @@
+package okta
+
+import "github.com/di-wu/scim-test-suite/report"
+
+const (
+	spec = "Okta SCIM 2.0 Spec Test"
+	// userOperations is the spec of the requests that Okta sends after a user has been created.
+	userOperations = "Okta SCIM 2.0 User Operations"
+)
+
+// References links the tests of the TestSuite to the (assertions of the) Okta SCIM 2.0 Spec Test and the user
+// operations of Okta.
+var References = report.References{
+	"TestGetFirstUser":                                  {Spec: spec, Section: "Test Users endpoint"},
+	"TestGetFirstUser/StatusCode":                       {Spec: spec, Section: "Test Users endpoint, Assertion 0"},
+	"TestGetFirstUser/ResourcesNotEmpty":                {Spec: spec, Section: "Test Users endpoint, Assertion 1"},
+	"TestGetFirstUser/ContainsSchema":                   {Spec: spec, Section: "Test Users endpoint, Assertion 2"},
+	"TestGetFirstUser/ItemsPerPageIsNumber":             {Spec: spec, Section: "Test Users endpoint, Assertion 3"},
+	"TestGetFirstUser/StartIndexIsNumber":               {Spec: spec, Section: "Test Users endpoint, Assertion 4"},
+	"TestGetFirstUser/TotalResultsIsNumber":             {Spec: spec, Section: "Test Users endpoint, Assertion 5"},
+	"TestGetFirstUser/IDNotEmpty":                       {Spec: spec, Section: "Test Users endpoint, Assertion 6"},
+	"TestGetFirstUser/FamilyNameNotEmpty":               {Spec: spec, Section: "Test Users endpoint, Assertion 7"},
+	"TestGetFirstUser/GivenNameNotEmpty":                {Spec: spec, Section: "Test Users endpoint, Assertion 8"},
+	"TestGetFirstUser/UserNameNotEmpty":                 {Spec: spec, Section: "Test Users endpoint, Assertion 9"},
+	"TestGetFirstUser/ActiveNotEmpty":                   {Spec: spec, Section: "Test Users endpoint, Assertion 10"},
+	"TestGetFirstUser/FirstEmailValueNotEmpty":          {Spec: spec, Section: "Test Users endpoint, Assertion 11"},
+	"TestGetExistingUser":                               {Spec: spec, Section: "Get Users/{{id}}"},
+	"TestGetExistingUser/StatusCode":                    {Spec: spec, Section: "Get Users/{{id}}, Assertion 0"},
+	"TestGetExistingUser/IDNotEmpty":                    {Spec: spec, Section: "Get Users/{{id}}, Assertion 1"},
+	"TestGetExistingUser/FamilyNameNotEmpty":            {Spec: spec, Section: "Get Users/{{id}}, Assertion 2"},
+	"TestGetExistingUser/GivenNameNotEmpty":             {Spec: spec, Section: "Get Users/{{id}}, Assertion 3"},
+	"TestGetExistingUser/UserNameNotEmpty":              {Spec: spec, Section: "Get Users/{{id}}, Assertion 4"},
+	"TestGetExistingUser/ActiveNotEmpty":                {Spec: spec, Section: "Get Users/{{id}}, Assertion 5"},
+	"TestGetExistingUser/FirstEmailValueNotEmpty":       {Spec: spec, Section: "Get Users/{{id}}, Assertion 6"},
+	"TestGetExistingUser/IDsMatch":                      {Spec: spec, Section: "Get Users/{{id}}, Assertion 7"},
+	"TestGetInvalidUserByUserName":                      {Spec: spec, Section: "Test invalid User by userName"},
+	"TestGetInvalidUserByUserName/StatusCode":           {Spec: spec, Section: "Test invalid User by userName, Assertion 0"},
+	"TestGetInvalidUserByUserName/ContainsSchema":       {Spec: spec, Section: "Test invalid User by userName, Assertion 1"},
+	"TestGetInvalidUserByUserName/NoResults":            {Spec: spec, Section: "Test invalid User by userName, Assertion 2"},
+	"TestGetInvalidUser":                                {Spec: spec, Section: "Test invalid User by ID"},
+	"TestGetInvalidUser/StatusCode":                     {Spec: spec, Section: "Test invalid User by ID, Assertion 0"},
+	"TestGetInvalidUser/DetailNotEmpty":                 {Spec: spec, Section: "Test invalid User by ID, Assertion 1"},
+	"TestGetInvalidUser/ContainsSchema":                 {Spec: spec, Section: "Test invalid User by ID, Assertion 2"},
+	"TestGetUserByRandomUserName":                       {Spec: spec, Section: "Make sure random user doesn't exist"},
+	"TestGetUserByRandomUserName/StatusCode":            {Spec: spec, Section: "Make sure random user doesn't exist, Assertion 0"},
+	"TestGetUserByRandomUserName/TotalResultsIsNumber0": {Spec: spec, Section: "Make sure random user doesn't exist, Assertion 1"},
+	"TestGetUserByRandomUserName/ContainsSchema":        {Spec: spec, Section: "Make sure random user doesn't exist, Assertion 2"},
+	"TestCreateUser":                                    {Spec: spec, Section: "Create Okta user with realistic values"},
+	"TestCreateUser/StatusCode":                         {Spec: spec, Section: "Create Okta user with realistic values, Assertion 0"},
+	"TestCreateUser/ActiveTrue":                         {Spec: spec, Section: "Create Okta user with realistic values, Assertion 1"},
+	"TestCreateUser/IDNotEmpty":                         {Spec: spec, Section: "Create Okta user with realistic values, Assertion 2"},
+	"TestCreateUser/FamilyNameMatches":                  {Spec: spec, Section: "Create Okta user with realistic values, Assertion 3"},
+	"TestCreateUser/GivenNameMatches":                   {Spec: spec, Section: "Create Okta user with realistic values, Assertion 4"},
+	"TestCreateUser/ContainsSchema":                     {Spec: spec, Section: "Create Okta user with realistic values, Assertion 5"},
+	"TestCreateUser/UserNameMatches":                    {Spec: spec, Section: "Create Okta user with realistic values, Assertion 6"},
+	"TestCreateUser/VerifyCreation":                     {Spec: spec, Section: "Verify that user was created"},
+	"TestCreateUser/VerifyCreation/StatusCode":          {Spec: spec, Section: "Verify that user was created, Assertion 0"},
+	"TestCreateUser/VerifyCreation/UserNameMatches":     {Spec: spec, Section: "Verify that user was created, Assertion 1"},
+	"TestCreateUser/VerifyCreation/FamilyNameMatches":   {Spec: spec, Section: "Verify that user was created, Assertion 2"},
+	"TestCreateUser/VerifyCreation/GivenNameMatches":    {Spec: spec, Section: "Verify that user was created, Assertion 3"},
+	"TestCreateUser/CreateDuplicate":                    {Spec: spec, Section: "Expect failure when recreating user with same values"},
+	"TestCreateUser/CreateDuplicate/StatusCode":         {Spec: spec, Section: "Expect failure when recreating user with same values, Assertion 0"},
+	"TestUserNameCS":                                    {Spec: spec, Section: "Username Case Sensitivity Check"},
+	"TestUserNameCS/StatusCode":                         {Spec: spec, Section: "Username Case Sensitivity Check, Assertion 0"},
+	"TestGetGroups":                                     {Spec: spec, Section: "Verify Groups endpoint"},
+	"TestGetGroups/StatusCode":                          {Spec: spec, Section: "Verify Groups endpoint, Assertion 0"},
+	"TestGetGroups/ResponseTime":                        {Spec: spec, Section: "Verify Groups endpoint, Assertion 1"},
+
+	"TestDeactivateUser/Deactivate":  {Spec: userOperations, Section: "Update a specific User (PATCH), deactivate"},
+	"TestDeactivateUser/Reactivate":  {Spec: userOperations, Section: "Update a specific User (PATCH), reactivate"},
+	"TestUpdateUserWithPut":          {Spec: userOperations, Section: "Update a specific User (PUT)"},
+	"TestPushPassword":               {Spec: userOperations, Section: "Update a specific User (PATCH), password"},
+	"TestReprovisionUser":            {Spec: userOperations, Section: "Retrieve Users by userName"},
+	"TestReprovisionUser/Reactivate": {Spec: userOperations, Section: "Update a specific User (PATCH), reactivate"},
+}
+
+const groupPushSpec = "Okta Group Push"
+
+// GroupPushReferences links the tests of the GroupPushSuite to the Group Push operations of Okta.
+var GroupPushReferences = report.References{
+	"TestGroupPush/FindByDisplayName": {Spec: groupPushSpec, Section: "Retrieve Groups by displayName"},
+	"TestGroupPush/CreateGroup":       {Spec: groupPushSpec, Section: "Create Group"},
+	"TestGroupPush/GetGroup":          {Spec: groupPushSpec, Section: "Retrieve Group by id"},
+	"TestGroupPush/FindCreatedGroup":  {Spec: groupPushSpec, Section: "Retrieve Groups by displayName"},
+	"TestGroupPush/AddMembers":        {Spec: groupPushSpec, Section: "Update Group membership (add)"},
+	"TestGroupPush/RemoveMember":      {Spec: groupPushSpec, Section: "Update Group membership (remove)"},
+	"TestGroupPush/RenameGroup":       {Spec: groupPushSpec, Section: "Update Group name"},
+	"TestGroupPush/ReplaceGroup":      {Spec: groupPushSpec, Section: "Update Group with PUT"},
+	"TestGroupPush/DeleteGroup":       {Spec: groupPushSpec, Section: "Delete Group"},
+}
@@ -40,7 +40,7 @@ func (s *TestSuite) RandomName() string {
 	}
 
 	gen, _ := regen.New(`^[a-zA-Z0-9]+`)
-	return gen.Generate()
+	return s.Namespaced(gen.Generate())
 }
 
 func (s *TestSuite) SetRandomEmail(random func() string) {
@@ -53,5 +53,5 @@ func (s *TestSuite) RandomEmail() string {
 	}
 
 	gen, _ := regen.New(`^[a-z0-9]+@[a-z0-9]+\.[a-z]{2,4}$`)
-	return gen.Generate()
+	return s.Namespaced(gen.Generate())
 }
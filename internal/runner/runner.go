@@ -0,0 +1,89 @@
+// Package runner holds the helpers that the Runscope and Postman runners share: resolving the placeholders of their
+// templates and converting values to text.
+package runner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// placeholder matches the variables and functions in a template, e.g. "{{SCIMBaseURL}}", "{{random_string(10)}}" or
+// "{{$guid}}".
+var placeholder = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+// Resolve replaces the placeholders in the template with the values of the variables, or else with the results of the
+// given functions. It returns the names of the placeholders that could not be resolved, they are left in place.
+func Resolve(template string, variables map[string]string, functions func(name string) (string, bool)) (string, []string) {
+	var missing []string
+	resolved := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		if v, ok := variables[name]; ok {
+			return v
+		}
+		if v, ok := functions(name); ok {
+			return v
+		}
+		missing = append(missing, name)
+		return match
+	})
+	return resolved, missing
+}
+
+// RandomString returns a random string of lowercase hexadecimal characters.
+func RandomString(n int) string {
+	const chars = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		c, _ := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		b[i] = chars[c.Int64()]
+	}
+	return string(b)
+}
+
+// RandomInt returns a random integer in [0, n).
+func RandomInt(n int64) string {
+	i, _ := rand.Int(rand.Reader, big.NewInt(n))
+	return i.String()
+}
+
+// UUID returns a random UUID.
+func UUID() string {
+	b := []byte(RandomString(32))
+	return fmt.Sprintf("%s-%s-%s-%s-%s", b[0:8], b[8:12], b[12:16], b[16:20], b[20:32])
+}
+
+// Stringify returns the text of a JSON value: strings as is, nil as an empty string and other values as JSON.
+func Stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	}
+}
+
+// Text is a string that also accepts numbers, booleans and null in JSON.
+type Text string
+
+func (t *Text) UnmarshalJSON(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if v != nil {
+		*t = Text(fmt.Sprint(v))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStringify(t *testing.T) {
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{"a", "a"},
+		{float64(1), "1"},
+		{1.5, "1.5"},
+		{json.Number("2"), "2"},
+		{true, "true"},
+		{[]interface{}{"a"}, `["a"]`},
+		{map[string]interface{}{"id": "1"}, `{"id":"1"}`},
+	} {
+		if actual := Stringify(test.value); actual != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.value, test.expected, actual)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	for raw, expected := range map[string]Text{
+		`"a"`:   "a",
+		`10`:    "10",
+		`1.50`:  "1.50",
+		`false`: "false",
+		`null`:  "",
+	} {
+		var text Text
+		if err := json.Unmarshal([]byte(raw), &text); err != nil || text != expected {
+			t.Errorf("%s: expected %q, got %q %v", raw, expected, text, err)
+		}
+	}
+	var text Text
+	if err := json.Unmarshal([]byte(`{`), &text); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestUUID(t *testing.T) {
+	if uuid := UUID(); len(uuid) != 36 || uuid[8] != '-' || uuid[23] != '-' {
+		t.Errorf("invalid uuid: %q", uuid)
+	}
+}
@@ -0,0 +1,157 @@
+package suite
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.5.2
+
+// patchBatchSize is the number of members that are added by a single PATCH request, identity providers push large
+// groups in batches as well.
+const patchBatchSize = 100
+
+func (suite *SCIMTestSuite) TestLargeGroup() {
+	size := suite.largeGroupSize
+	if size == 0 {
+		size = defaultLargeGroupSize
+	}
+	if size < 0 || testing.Short() {
+		suite.T().Skip("large group tests are disabled")
+	}
+
+	var (
+		userIDs = make([]string, size)
+		members = make([]map[string]interface{}, size)
+	)
+	start := time.Now()
+	for i := range userIDs {
+		userIDs[i] = suite.createUser(fmt.Sprintf("large%05d", i))
+		members[i] = map[string]interface{}{
+			"value": userIDs[i],
+		}
+	}
+	suite.T().Logf("creating %d users took %s", size, time.Since(start))
+
+	var postID, patchID string
+	suite.Run("Post", func() {
+		start := time.Now()
+		resp := suite.Post("/Groups", suite.groupBody(suite.Namespaced("large-post"), members...))
+		suite.T().Logf("POST with %d members took %s", size, time.Since(start))
+		suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		postID = suite.GetString("id", suite.ReadAllToMap(resp))
+		suite.Len(suite.members(postID), size)
+	})
+
+	suite.Run("PatchAdd", func() {
+		patchID = suite.createGroup("large-patch")
+		start := time.Now()
+		for i := 0; i < size; i += patchBatchSize {
+			end := i + patchBatchSize
+			if end > size {
+				end = size
+			}
+			resp := suite.Patch(fmt.Sprintf("/Groups/%s", patchID), suite.patchBody(map[string]interface{}{
+				"op":    "add",
+				"path":  "members",
+				"value": members[i:end],
+			}))
+			suite.Require().Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+		}
+		suite.T().Logf("PATCH adding %d members in batches of %d took %s", size, patchBatchSize, time.Since(start))
+		suite.Len(suite.members(patchID), size)
+	})
+
+	suite.Run("PatchResponse", func() {
+		// The response of a PATCH is either 204 (No Content) or the resource, subject to the "attributes" parameter.
+		// Returning all members of a large group on every change is what makes pushes of identity providers time out.
+		suite.Require().NotEmpty(patchID)
+		resp := suite.Patch(fmt.Sprintf("/Groups/%s", patchID), suite.patchBody(map[string]interface{}{
+			"op":    "add",
+			"path":  "members",
+			"value": members[:1],
+		}))
+		if resp.StatusCode == http.StatusNoContent {
+			return
+		}
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+		returned, _ := suite.ReadAllToMap(resp)["members"].([]interface{})
+		suite.Should().Less(len(returned), size, "all members were returned without being requested")
+	})
+
+	suite.Run("PatchAttributes", func() {
+		// The members are returned when they are requested with the "attributes" parameter.
+		suite.Require().NotEmpty(patchID)
+		resp := suite.Patch(fmt.Sprintf("/Groups/%s?attributes=members", patchID), suite.patchBody(map[string]interface{}{
+			"op":    "add",
+			"path":  "members",
+			"value": members[:1],
+		}))
+		if resp.StatusCode == http.StatusNoContent {
+			return
+		}
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+		returned, _ := suite.ReadAllToMap(resp)["members"].([]interface{})
+		suite.Should().Len(returned, size)
+	})
+
+	suite.Run("RemoveMembers", func() {
+		// Members are removed with a value filter on the path, e.g. 'members[value eq "2819c223..."]'.
+		suite.Require().NotEmpty(postID)
+		var (
+			operations []map[string]interface{}
+			removed    = make(map[string]bool)
+		)
+		for i := 0; i < size; i += 10 {
+			operations = append(operations, map[string]interface{}{
+				"op":   "remove",
+				"path": fmt.Sprintf("members[value eq \"%s\"]", userIDs[i]),
+			})
+			removed[userIDs[i]] = true
+		}
+
+		start := time.Now()
+		resp := suite.Patch(fmt.Sprintf("/Groups/%s", postID), suite.patchBody(operations...))
+		suite.T().Logf("PATCH removing %d members took %s", len(removed), time.Since(start))
+		suite.Require().Contains([]int{http.StatusOK, http.StatusNoContent}, resp.StatusCode)
+
+		remaining := suite.members(postID)
+		suite.Len(remaining, size-len(removed))
+		for _, m := range remaining {
+			member := suite.IsMap(m)
+			suite.False(removed[suite.GetString("value", member)], "removed member is still part of the group")
+		}
+	})
+
+	suite.Run("ExcludedAttributes", func() {
+		// The members of a group can be left out of the response with the "excludedAttributes" parameter.
+		suite.Require().NotEmpty(postID)
+		start := time.Now()
+		resp := suite.Get(fmt.Sprintf("/Groups/%s?excludedAttributes=members", postID))
+		suite.T().Logf("GET without members took %s", time.Since(start))
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		group := suite.ReadAllToMap(resp)
+		suite.Equal(postID, suite.GetString("id", group))
+		_, ok := group["members"]
+		suite.Should().False(ok, "the members were returned")
+	})
+}
+
+// members returns the members of the group.
+func (suite *SCIMTestSuite) members(groupID string) []interface{} {
+	group := suite.ReadAllToMap(suite.GetOk(fmt.Sprintf("/Groups/%s", groupID)))
+	members, _ := group["members"].([]interface{})
+	return members
+}
+
+// patchBody returns the body of a PATCH request with the given operations.
+func (suite *SCIMTestSuite) patchBody(operations ...map[string]interface{}) *bytes.Reader {
+	return suite.Body(util.PatchBody(operations...))
+}
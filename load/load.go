@@ -0,0 +1,262 @@
+// Package load drives sustained create, get, list, patch and delete traffic for users and groups against a SCIM
+// server and reports the latency and error rates per endpoint. It uses the generic request bodies of the SCIM suite,
+// the bodies of the IdP suites (e.g. Okta and Azure AD) are not sent.
+package load
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// Endpoints of which the traffic gets reported, identifiers are replaced by "{id}".
+const (
+	CreateUser = "POST /Users"
+	GetUser    = "GET /Users/{id}"
+	ListUsers  = "GET /Users"
+	PatchUser  = "PATCH /Users/{id}"
+	DeleteUser = "DELETE /Users/{id}"
+
+	CreateGroup = "POST /Groups"
+	GetGroup    = "GET /Groups/{id}"
+	// PatchGroup are the PATCH requests that remove and add a member of a group.
+	PatchGroup  = "PATCH /Groups/{id}"
+	DeleteGroup = "DELETE /Groups/{id}"
+)
+
+// Config configures a load run.
+type Config struct {
+	// URL is the base url of the SCIM server, e.g. https://path.to.scim/v2.
+	URL string
+	// Rate is the maximum number of requests per second over all workers, it is unlimited if zero.
+	Rate float64
+	// Duration is how long new iterations are started, the ones that are running get finished.
+	Duration time.Duration
+	// Concurrency is the number of workers, every worker runs the create, get, list, patch and delete requests of a
+	// new user and of a new group with that user as member after one another.
+	Concurrency int
+	// MaxRetries is the number of times a request is retried after a 429 (Too Many Requests).
+	MaxRetries int
+	// Middleware is called for every request, e.g. to add authentication.
+	Middleware func(req *http.Request) *http.Request
+	// Namespace is the prefix of the names of the created users, see util.NamespacePrefix.
+	Namespace string
+	// Client is the client that sends the requests, http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// Run drives traffic against the server with the given configuration until the duration has passed or the context
+// is done, and returns the statistics per endpoint.
+func Run(ctx context.Context, config Config) *Report {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Namespace == "" {
+		config.Namespace = util.NamespacePrefix + "load-"
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+
+	r := runner{
+		config: config,
+		report: newReport(),
+		tokens: limit(ctx, config.Rate),
+	}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				r.iteration()
+			}
+		}()
+	}
+	wg.Wait()
+
+	r.report.Duration = time.Since(start)
+	return r.report
+}
+
+// limit returns a channel that lets through the given number of requests per second, or nil if the rate is
+// unlimited.
+func limit(ctx context.Context, rate float64) <-chan struct{} {
+	if rate <= 0 {
+		return nil
+	}
+	tokens := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				close(tokens)
+				return
+			case <-ticker.C:
+				select {
+				case tokens <- struct{}{}:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return tokens
+}
+
+type runner struct {
+	config Config
+	report *Report
+	tokens <-chan struct{}
+	n      int64
+}
+
+// iteration creates, gets, lists, patches and deletes a new user, and a new group with that user as member. The
+// resources are always deleted once they are created, even if the run is over.
+func (r *runner) iteration() {
+	userName := fmt.Sprintf("%s%d-%d", r.config.Namespace, time.Now().UnixNano(), atomic.AddInt64(&r.n, 1))
+	userID, ok := r.create(CreateUser, "/Users", util.UserBody(userName))
+	if !ok {
+		return
+	}
+	path := fmt.Sprintf("/Users/%s", url.PathEscape(userID))
+	defer r.do(DeleteUser, http.MethodDelete, path, nil)
+
+	r.do(GetUser, http.MethodGet, path, nil)
+	r.do(ListUsers, http.MethodGet, fmt.Sprintf("/Users?%s", url.Values{
+		"filter": []string{fmt.Sprintf("userName eq %q", userName)},
+	}.Encode()), nil)
+	r.do(PatchUser, http.MethodPatch, path, util.PatchBody(map[string]interface{}{
+		"op":    "replace",
+		"path":  "displayName",
+		"value": userName,
+	}))
+
+	member := map[string]interface{}{"value": userID}
+	groupID, ok := r.create(CreateGroup, "/Groups", util.GroupBody(userName, member))
+	if !ok {
+		return
+	}
+	path = fmt.Sprintf("/Groups/%s", url.PathEscape(groupID))
+	defer r.do(DeleteGroup, http.MethodDelete, path, nil)
+
+	r.do(GetGroup, http.MethodGet, path, nil)
+	r.do(PatchGroup, http.MethodPatch, path, util.PatchBody(map[string]interface{}{
+		"op":   "remove",
+		"path": fmt.Sprintf("members[value eq %q]", userID),
+	}))
+	r.do(PatchGroup, http.MethodPatch, path, util.PatchBody(map[string]interface{}{
+		"op":    "add",
+		"path":  "members",
+		"value": []interface{}{member},
+	}))
+}
+
+// create creates a resource and returns its id, it returns false if the resource was not created.
+func (r *runner) create(endpoint, path string, body interface{}) (string, bool) {
+	resp, ok := r.do(endpoint, http.MethodPost, path, body)
+	if !ok || resp.StatusCode != http.StatusCreated {
+		return "", false
+	}
+	var resource struct {
+		ID string
+	}
+	if err := json.Unmarshal(resp.body, &resource); err != nil || resource.ID == "" {
+		return "", false
+	}
+	return resource.ID, true
+}
+
+type response struct {
+	StatusCode int
+	body       []byte
+}
+
+// do sends the request and records it for the given endpoint. Requests that are rate limited are retried after the
+// delay of the Retry-After header. It returns false if no response was received.
+func (r *runner) do(endpoint, method, path string, body interface{}) (response, bool) {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return response{}, false
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if r.tokens != nil {
+			// the channel is closed once the run is over, remaining requests (e.g. deletes) are not limited.
+			<-r.tokens
+		}
+
+		resp, latency, err := r.send(method, path, raw)
+		r.report.record(endpoint, resp, latency, err)
+		if err != nil {
+			return response{}, false
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= r.config.MaxRetries {
+			return resp.response, true
+		}
+		time.Sleep(resp.retryAfter)
+	}
+}
+
+// send sends a single request and returns the response and its latency.
+func (r *runner) send(method, path string, body []byte) (retriable, time.Duration, error) {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, r.config.URL+path, reader)
+	if err != nil {
+		return retriable{}, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	if r.config.Middleware != nil {
+		req = r.config.Middleware(req)
+	}
+
+	start := time.Now()
+	resp, err := r.config.Client.Do(req)
+	if err != nil {
+		return retriable{}, time.Since(start), err
+	}
+	raw, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	latency := time.Since(start)
+	if err != nil {
+		return retriable{}, latency, err
+	}
+	return retriable{
+		response:      response{StatusCode: resp.StatusCode, body: raw},
+		hasRetryAfter: resp.Header.Get("Retry-After") != "",
+		retryAfter:    util.RetryAfter(resp.Header.Get("Retry-After"), time.Second),
+	}, latency, nil
+}
+
+// retriable is a response with the delay after which the request can be retried.
+type retriable struct {
+	response
+	hasRetryAfter bool
+	retryAfter    time.Duration
+}
@@ -0,0 +1,161 @@
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// endpoints is the order in which the endpoints are reported.
+var endpoints = []string{
+	CreateUser, GetUser, ListUsers, PatchUser, DeleteUser,
+	CreateGroup, GetGroup, PatchGroup, DeleteGroup,
+}
+
+// Report contains the statistics of a load run per endpoint.
+type Report struct {
+	Duration  time.Duration
+	Endpoints map[string]*Stats
+
+	mu sync.Mutex
+}
+
+func newReport() *Report {
+	return &Report{
+		Endpoints: make(map[string]*Stats),
+	}
+}
+
+// Stats are the statistics of the requests to a single endpoint. Every retry counts as a request.
+type Stats struct {
+	Requests int
+	// Errors are the requests that failed or got a response with an error status code, except for 429.
+	Errors int
+	// RateLimited are the requests that got a 429 (Too Many Requests) response.
+	RateLimited int
+	// MissingRetryAfter are the 429 responses without a Retry-After header.
+	MissingRetryAfter int
+
+	latencies []time.Duration
+	sorted    bool
+}
+
+// record records the response of a request to the given endpoint.
+func (r *Report) record(endpoint string, resp retriable, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.Endpoints[endpoint]
+	if !ok {
+		s = new(Stats)
+		r.Endpoints[endpoint] = s
+	}
+	s.Requests++
+	s.latencies = append(s.latencies, latency)
+	s.sorted = false
+	switch {
+	case err != nil:
+		s.Errors++
+	case resp.StatusCode == http.StatusTooManyRequests:
+		s.RateLimited++
+		if !resp.hasRetryAfter {
+			s.MissingRetryAfter++
+		}
+	case resp.StatusCode >= http.StatusBadRequest:
+		s.Errors++
+	}
+}
+
+// ErrorRate returns the fraction of the requests that failed.
+func (s *Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Percentile returns the latency below which the given percentage (0-100) of the requests fall.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	if !s.sorted {
+		sort.Slice(s.latencies, func(i, j int) bool {
+			return s.latencies[i] < s.latencies[j]
+		})
+		s.sorted = true
+	}
+	i := int(p / 100 * float64(len(s.latencies)))
+	if i >= len(s.latencies) {
+		i = len(s.latencies) - 1
+	}
+	return s.latencies[i]
+}
+
+// WriteText writes the report as a table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "endpoint\trequests\trate\tp50\tp95\tp99\terrors\t429\t429 w/o Retry-After\t\n")
+	for _, endpoint := range endpoints {
+		s, ok := r.Endpoints[endpoint]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f/s\t%s\t%s\t%s\t%.2f%%\t%d\t%d\t\n",
+			endpoint, s.Requests, float64(s.Requests)/r.Duration.Seconds(),
+			round(s.Percentile(50)), round(s.Percentile(95)), round(s.Percentile(99)),
+			100*s.ErrorRate(), s.RateLimited, s.MissingRetryAfter,
+		)
+	}
+	fmt.Fprintf(tw, "\nduration: %s\n", round(r.Duration))
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as JSON, latencies are in milliseconds.
+func (r *Report) WriteJSON(w io.Writer) error {
+	type stats struct {
+		Requests          int     `json:"requests"`
+		Errors            int     `json:"errors"`
+		ErrorRate         float64 `json:"errorRate"`
+		RateLimited       int     `json:"rateLimited"`
+		MissingRetryAfter int     `json:"missingRetryAfter"`
+		P50               float64 `json:"p50"`
+		P95               float64 `json:"p95"`
+		P99               float64 `json:"p99"`
+	}
+	report := struct {
+		Duration  float64          `json:"duration"`
+		Endpoints map[string]stats `json:"endpoints"`
+	}{
+		Duration:  r.Duration.Seconds(),
+		Endpoints: make(map[string]stats),
+	}
+	for endpoint, s := range r.Endpoints {
+		report.Endpoints[endpoint] = stats{
+			Requests:          s.Requests,
+			Errors:            s.Errors,
+			ErrorRate:         s.ErrorRate(),
+			RateLimited:       s.RateLimited,
+			MissingRetryAfter: s.MissingRetryAfter,
+			P50:               milliseconds(s.Percentile(50)),
+			P95:               milliseconds(s.Percentile(95)),
+			P99:               milliseconds(s.Percentile(99)),
+		}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(report)
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,126 @@
+// Package postman runs the requests and tests of a Postman (v2.1) collection, like the SCIM validator collection of
+// Azure AD, against a SCIM server. The tests are JavaScript, a subset of pm.test/pm.expect assertions is translated;
+// tests that can not be translated are skipped instead of passing silently.
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+// Collection is a Postman collection in the v2.1 format.
+type Collection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Items     []Item     `json:"item"`
+	Events    []Event    `json:"event"`
+	Variables []Variable `json:"variable"`
+}
+
+// Item is either a folder with items, or a request.
+type Item struct {
+	Name    string   `json:"name"`
+	Items   []Item   `json:"item"`
+	Request *Request `json:"request"`
+	Events  []Event  `json:"event"`
+}
+
+// Request is the request of an item.
+type Request struct {
+	Method string     `json:"method"`
+	Header []KeyValue `json:"header"`
+	Body   *Body      `json:"body"`
+	URL    URL        `json:"url"`
+}
+
+// KeyValue is a header of a request.
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// Body is the body of a request, only raw bodies are supported.
+type Body struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+// URL is the URL of a request, it is either a string or an object with the raw URL.
+type URL struct {
+	Raw string `json:"raw"`
+}
+
+func (u *URL) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		return json.Unmarshal(data, &u.Raw)
+	}
+	type url URL
+	return json.Unmarshal(data, (*url)(u))
+}
+
+// Event is a script that runs before ("prerequest") or after ("test") a request.
+type Event struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec lines `json:"exec"`
+	} `json:"script"`
+}
+
+// lines are the lines of a script, they are either a string or a list of strings.
+type lines string
+
+func (l *lines) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		return json.Unmarshal(data, (*string)(l))
+	}
+	var exec []string
+	if err := json.Unmarshal(data, &exec); err != nil {
+		return err
+	}
+	*l = lines(strings.Join(exec, "\n"))
+	return nil
+}
+
+// Variable is a collection variable.
+type Variable struct {
+	Key   string      `json:"key"`
+	Value runner.Text `json:"value"`
+}
+
+// Load reads a Postman collection.
+func Load(r io.Reader) (*Collection, error) {
+	var c Collection
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid postman collection: %v", err)
+	}
+	return &c, nil
+}
+
+// LoadFile reads the Postman collection in the given file.
+func LoadFile(name string) (*Collection, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// script returns the scripts of the events that listen to the given event.
+func script(events []Event, listen string) string {
+	var scripts []string
+	for _, e := range events {
+		if e.Listen == listen {
+			scripts = append(scripts, string(e.Script.Exec))
+		}
+	}
+	return strings.Join(scripts, "\n")
+}
@@ -0,0 +1,608 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+// response is the response that the test scripts refer to.
+type response struct {
+	status  int
+	header  http.Header
+	body    string
+	latency time.Duration
+}
+
+// env is the state of a script: the response (nil in a pre-request script), the variables and the local variables of
+// the script.
+type env struct {
+	resp      *response
+	variables map[string]string
+	locals    map[string]interface{}
+}
+
+// test is a translated pm.test (or legacy tests[...]) assertion.
+type test struct {
+	name string
+	// untranslated is the statement of the test that could not be translated, the test is skipped if it is set.
+	untranslated string
+	statements   []statement
+}
+
+// statement is a translated statement, it returns an error if an assertion does not hold.
+type statement func(e *env) error
+
+// translated is a translated script: the statements outside of the tests and the tests.
+type translated struct {
+	statements []statement
+	tests      []test
+	// ignored are the statements outside of the tests that could not be translated.
+	ignored []string
+}
+
+var (
+	local       = regexp.MustCompile(`^(?:var|let|const)\s+([A-Za-z_$][\w$]*)\s*=\s*([\s\S]+)$`)
+	setVariable = regexp.MustCompile(`^(?:pm\.(?:environment|variables|collectionVariables|globals)\.set|postman\.set(?:Environment|Global)Variable)\(\s*(?:"([^"]*)"|'([^']*)')\s*,\s*([\s\S]+)\)$`)
+	legacyTest  = regexp.MustCompile(`^tests\[\s*(?:"([^"]*)"|'([^']*)')\s*]\s*=\s*([\s\S]+)$`)
+	comparison  = regexp.MustCompile(`^([\s\S]+?)\s*(===|!==|==|!=)\s*([\s\S]+)$`)
+	status      = regexp.MustCompile(`^pm\.response\.to\.(?:have\.status\((\d+)\)|be\.(ok|success))$`)
+)
+
+// translate translates the statements of a script.
+func translate(src string) translated {
+	var t translated
+	for _, stmt := range split(src) {
+		if strings.HasPrefix(stmt, "pm.test(") {
+			t.tests = append(t.tests, translateTest(stmt))
+			continue
+		}
+		if m := legacyTest.FindStringSubmatch(stmt); m != nil {
+			test := test{name: m[1] + m[2]}
+			if s, err := translateCondition(m[3]); err != nil {
+				test.untranslated = stmt
+			} else {
+				test.statements = []statement{s}
+			}
+			t.tests = append(t.tests, test)
+			continue
+		}
+		s, err := translateStatement(stmt)
+		if err != nil {
+			t.ignored = append(t.ignored, stmt)
+			continue
+		}
+		t.statements = append(t.statements, s)
+	}
+	return t
+}
+
+// translateTest translates a pm.test("name", function () { ... }) statement.
+func translateTest(stmt string) test {
+	a, ok := inner(stmt[len("pm.test"):], '(', ')')
+	args := split(a, ',')
+	if !ok || len(args) < 2 {
+		return test{name: stmt, untranslated: stmt}
+	}
+	var name string
+	if err := json.Unmarshal([]byte(quote(args[0])), &name); err != nil {
+		return test{name: args[0], untranslated: stmt}
+	}
+
+	t := test{name: name}
+	body, ok := inner(strings.Join(args[1:], ","), '{', '}')
+	if !ok {
+		t.untranslated = stmt
+		return t
+	}
+	for _, s := range split(body) {
+		translated, err := translateStatement(s)
+		if err != nil {
+			t.untranslated = s
+			return t
+		}
+		t.statements = append(t.statements, translated)
+	}
+	return t
+}
+
+// translateStatement translates a statement: the assignment of a local variable, the setting of a variable or an
+// assertion.
+func translateStatement(stmt string) (statement, error) {
+	if m := local.FindStringSubmatch(stmt); m != nil {
+		name, expr := m[1], m[2]
+		return func(e *env) error {
+			v, err := e.eval(expr)
+			if err != nil {
+				return err
+			}
+			e.locals[name] = v
+			return nil
+		}, nil
+	}
+	if m := setVariable.FindStringSubmatch(stmt); m != nil {
+		name, expr := m[1]+m[2], m[3]
+		return func(e *env) error {
+			v, err := e.eval(expr)
+			if err != nil {
+				return err
+			}
+			e.variables[name] = stringify(v)
+			return nil
+		}, nil
+	}
+	if m := status.FindStringSubmatch(stmt); m != nil {
+		return func(e *env) error {
+			if e.resp == nil {
+				return fmt.Errorf("no response")
+			}
+			switch {
+			case m[1] != "":
+				if strconv.Itoa(e.resp.status) != m[1] {
+					return fmt.Errorf("expected status %s, got %d", m[1], e.resp.status)
+				}
+			case m[2] == "ok" && e.resp.status != http.StatusOK,
+				m[2] == "success" && (e.resp.status < 200 || e.resp.status > 299):
+				return fmt.Errorf("expected status to be %s, got %d", m[2], e.resp.status)
+			}
+			return nil
+		}, nil
+	}
+	if strings.HasPrefix(stmt, "pm.expect(") {
+		return translateExpect(stmt)
+	}
+	return nil, fmt.Errorf("untranslated statement: %s", stmt)
+}
+
+// chain matches a part of an assertion chain, e.g. ".to", ".not" or ".eql(".
+var chain = regexp.MustCompile(`^\.([A-Za-z]+)(\()?`)
+
+// translateExpect translates a pm.expect(actual).to...(expected) assertion.
+func translateExpect(stmt string) (statement, error) {
+	rest := stmt[len("pm.expect"):]
+	actual, ok := inner(rest, '(', ')')
+	if !ok {
+		return nil, fmt.Errorf("untranslated assertion: %s", stmt)
+	}
+	rest = rest[len(actual)+2:]
+
+	var (
+		negate  bool
+		matcher string
+		args    []string
+	)
+	for rest != "" {
+		m := chain.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("untranslated assertion: %s", stmt)
+		}
+		rest = rest[len(m[0]):]
+		word := m[1]
+		if m[2] != "" {
+			a, ok := inner("("+rest, '(', ')')
+			if !ok {
+				return nil, fmt.Errorf("untranslated assertion: %s", stmt)
+			}
+			rest = rest[len(a)+1:]
+			args = split(a, ',')
+			matcher = word
+			continue
+		}
+		switch word {
+		case "to", "be", "been", "is", "that", "which", "and", "has", "have", "with", "at", "of", "same", "deep":
+		case "not":
+			negate = !negate
+		default:
+			matcher = word
+		}
+	}
+	match, ok := matchers[matcher]
+	if !ok {
+		return nil, fmt.Errorf("untranslated assertion: %s", stmt)
+	}
+
+	return func(e *env) error {
+		a, err := e.eval(actual)
+		if err != nil {
+			return err
+		}
+		var expected []interface{}
+		for _, arg := range args {
+			v, err := e.eval(arg)
+			if err != nil {
+				return err
+			}
+			expected = append(expected, v)
+		}
+		if match(a, expected) == negate {
+			not := ""
+			if negate {
+				not = "not "
+			}
+			return fmt.Errorf("expected %s to %s%s %s", stringify(a), not, matcher, stringify(expected))
+		}
+		return nil
+	}, nil
+}
+
+// translateCondition translates the condition of a legacy test, e.g. responseCode.code === 201.
+func translateCondition(expr string) (statement, error) {
+	m := comparison.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("untranslated condition: %s", expr)
+	}
+	left, op, right := m[1], m[2], m[3]
+	return func(e *env) error {
+		l, err := e.eval(left)
+		if err != nil {
+			return err
+		}
+		r, err := e.eval(right)
+		if err != nil {
+			return err
+		}
+		if equal(l, r) != (op == "===" || op == "==") {
+			return fmt.Errorf("expected %s %s %s", stringify(l), op, stringify(r))
+		}
+		return nil
+	}, nil
+}
+
+// matchers are the translated chai assertions, they return whether the actual value matches the expected values.
+var matchers = map[string]func(actual interface{}, expected []interface{}) bool{
+	"eql":     func(a interface{}, e []interface{}) bool { return len(e) == 1 && equal(a, e[0]) },
+	"include": func(a interface{}, e []interface{}) bool { return len(e) == 1 && includes(a, e[0]) },
+	"lengthOf": func(a interface{}, e []interface{}) bool {
+		n, ok := length(a)
+		return ok && len(e) == 1 && equal(float64(n), e[0])
+	},
+	"above": func(a interface{}, e []interface{}) bool { return len(e) == 1 && less(e[0], a) },
+	"below": func(a interface{}, e []interface{}) bool { return len(e) == 1 && less(a, e[0]) },
+	"oneOf": func(a interface{}, e []interface{}) bool {
+		if len(e) != 1 {
+			return false
+		}
+		values, ok := e[0].([]interface{})
+		return ok && includes(values, a)
+	},
+	"property": func(a interface{}, e []interface{}) bool {
+		m, ok := a.(map[string]interface{})
+		if !ok || len(e) == 0 {
+			return false
+		}
+		v, ok := m[stringify(e[0])]
+		return ok && (len(e) == 1 || equal(v, e[1]))
+	},
+	"a":     func(a interface{}, e []interface{}) bool { return len(e) == 1 && typeOf(a) == stringify(e[0]) },
+	"true":  func(a interface{}, _ []interface{}) bool { return a == true },
+	"false": func(a interface{}, _ []interface{}) bool { return a == false },
+	"null":  func(a interface{}, _ []interface{}) bool { return a == nil },
+	"exist": func(a interface{}, _ []interface{}) bool { return a != nil },
+	"ok":    func(a interface{}, _ []interface{}) bool { return truthy(a) },
+	"empty": func(a interface{}, _ []interface{}) bool {
+		n, ok := length(a)
+		return ok && n == 0
+	},
+}
+
+func init() {
+	for alias, name := range map[string]string{
+		"eq": "eql", "equal": "eql", "equals": "eql",
+		"includes": "include", "contain": "include", "contains": "include",
+		"length": "lengthOf",
+		"gt":     "above", "greaterThan": "above",
+		"lt": "below", "lessThan": "below",
+		"an": "a", "undefined": "null",
+	} {
+		matchers[alias] = matchers[name]
+	}
+}
+
+var (
+	identifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*`)
+	variable   = regexp.MustCompile(`^(?:pm\.(?:environment|variables|collectionVariables|globals)\.get|postman\.get(?:Environment|Global)Variable)\(\s*(?:"([^"]*)"|'([^']*)')\s*\)`)
+	header     = regexp.MustCompile(`^(?:pm\.response\.headers\.get|postman\.getResponseHeader)\(\s*(?:"([^"]*)"|'([^']*)')\s*\)`)
+	accessor   = regexp.MustCompile(`^(?:\.([A-Za-z_$][\w$]*)|\[(\d+)]|\[\s*(?:"([^"]*)"|'([^']*)')\s*])`)
+)
+
+// eval evaluates an expression: a literal, a value of the response or a variable, followed by properties.
+func (e *env) eval(expr string) (interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	if v, ok := literal(expr); ok {
+		return v, nil
+	}
+
+	var (
+		v    interface{}
+		rest string
+	)
+	switch {
+	case strings.HasPrefix(expr, "pm.response.json()"), strings.HasPrefix(expr, "JSON.parse(responseBody)"):
+		if e.resp == nil {
+			return nil, fmt.Errorf("no response")
+		}
+		if err := json.Unmarshal([]byte(e.resp.body), &v); err != nil {
+			return nil, fmt.Errorf("the response is not JSON")
+		}
+		rest = expr[strings.Index(expr, ")")+1:]
+	case strings.HasPrefix(expr, "pm.response.text()"), strings.HasPrefix(expr, "responseBody"):
+		if e.resp == nil {
+			return nil, fmt.Errorf("no response")
+		}
+		v = e.resp.body
+		rest = strings.TrimPrefix(strings.TrimPrefix(expr, "pm.response.text()"), "responseBody")
+	case strings.HasPrefix(expr, "pm.response.code"), strings.HasPrefix(expr, "responseCode.code"):
+		if e.resp == nil {
+			return nil, fmt.Errorf("no response")
+		}
+		v = float64(e.resp.status)
+		rest = strings.TrimPrefix(strings.TrimPrefix(expr, "pm.response.code"), "responseCode.code")
+	case strings.HasPrefix(expr, "pm.response.responseTime"), strings.HasPrefix(expr, "responseTime"):
+		if e.resp == nil {
+			return nil, fmt.Errorf("no response")
+		}
+		v = float64(e.resp.latency.Milliseconds())
+		rest = strings.TrimPrefix(strings.TrimPrefix(expr, "pm.response.responseTime"), "responseTime")
+	case header.MatchString(expr):
+		if e.resp == nil {
+			return nil, fmt.Errorf("no response")
+		}
+		m := header.FindStringSubmatch(expr)
+		if values, ok := e.resp.header[http.CanonicalHeaderKey(m[1]+m[2])]; ok {
+			v = strings.Join(values, ", ")
+		}
+		rest = expr[len(m[0]):]
+	case variable.MatchString(expr):
+		m := variable.FindStringSubmatch(expr)
+		if value, ok := e.variables[m[1]+m[2]]; ok {
+			v = value
+		}
+		rest = expr[len(m[0]):]
+	case identifier.MatchString(expr):
+		name := identifier.FindString(expr)
+		local, ok := e.locals[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown identifier: %s", name)
+		}
+		v, rest = local, expr[len(name):]
+	default:
+		return nil, fmt.Errorf("untranslated expression: %s", expr)
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		m := accessor.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("untranslated expression: %s", expr)
+		}
+		rest = rest[len(m[0]):]
+		switch {
+		case m[2] != "":
+			i, _ := strconv.Atoi(m[2])
+			values, ok := v.([]interface{})
+			if !ok || i >= len(values) {
+				v = nil
+				continue
+			}
+			v = values[i]
+		case m[1] == "length":
+			n, ok := length(v)
+			if !ok {
+				return nil, fmt.Errorf("length of %s", stringify(v))
+			}
+			v = float64(n)
+		default:
+			object, _ := v.(map[string]interface{})
+			v = object[m[1]+m[3]+m[4]]
+		}
+	}
+	return v, nil
+}
+
+// literal parses a JSON literal, single quoted strings are supported as well.
+func literal(expr string) (interface{}, bool) {
+	if expr == "undefined" {
+		return nil, true
+	}
+	if expr == "" || !strings.ContainsAny(expr[:1], `"'-0123456789[{`) && expr != "true" && expr != "false" &&
+		expr != "null" {
+		return nil, false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(quote(expr)), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// quote converts a single quoted string into a double quoted one.
+func quote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return s
+	}
+	raw, _ := json.Marshal(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`))
+	return string(raw)
+}
+
+// split splits the source on the given separators outside of strings, parentheses, brackets and braces. Comments are
+// removed and the parts are trimmed, empty parts are left out. The default separators are ";" and new lines.
+func split(src string, separators ...rune) []string {
+	if len(separators) == 0 {
+		separators = []rune{';', '\n'}
+	}
+	var (
+		parts   []string
+		current strings.Builder
+		depth   int
+		quote   rune
+		runes   = []rune(src)
+	)
+	flush := func() {
+		if part := strings.TrimSpace(current.String()); part != "" {
+			parts = append(parts, part)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && i+1 < len(runes) {
+				current.WriteRune(r)
+				i++
+				r = runes[i]
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			i--
+			continue
+		case strings.ContainsRune("([{", r):
+			depth++
+		case strings.ContainsRune(")]}", r):
+			depth--
+		case depth == 0 && strings.ContainsRune(string(separators), r):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return parts
+}
+
+// inner returns the content between the first opening character and its closing character. It returns false if
+// there is no opening character or it is not closed.
+func inner(s string, open, close rune) (string, bool) {
+	start := strings.IndexRune(s, open)
+	if start == -1 {
+		return "", false
+	}
+	var (
+		depth int
+		quote rune
+		runes = []rune(s[start:])
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == open:
+			depth++
+		case r == close:
+			if depth--; depth == 0 {
+				return string(runes[1:i]), true
+			}
+		}
+	}
+	return "", false
+}
+
+// stringify returns the value as JavaScript converts it to a string, undefined values included.
+func stringify(v interface{}) string {
+	if v == nil {
+		return "undefined"
+	}
+	return runner.Stringify(v)
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// includes returns whether the string contains the substring, the array contains the value or the object contains
+// the properties of the expected object.
+func includes(v, expected interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		s, ok := expected.(string)
+		return ok && strings.Contains(v, s)
+	case []interface{}:
+		for _, value := range v {
+			if equal(value, expected) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		properties, ok := expected.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, e := range properties {
+			if !equal(v[k], e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func length(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case string:
+		return len(v), true
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	}
+	return 0, false
+}
+
+func less(a, b interface{}) bool {
+	x, ok := a.(float64)
+	y, ok2 := b.(float64)
+	return ok && ok2 && x < y
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	}
+	return true
+}
+
+// typeOf returns the type of the value as it is named by chai, e.g. "string" or "array".
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
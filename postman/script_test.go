@@ -0,0 +1,363 @@
+package postman
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testResponse is the response the scripts in the tests run against.
+var testResponse = response{
+	status: http.StatusCreated,
+	header: http.Header{"Content-Type": {"application/scim+json"}},
+	body: `{
+		"id": "1",
+		"userName": "bjensen",
+		"active": true,
+		"emails": [{"value": "bjensen@example.com", "primary": true}],
+		"groups": [],
+		"meta": {"resourceType": "User"}
+	}`,
+	latency: 150 * time.Millisecond,
+}
+
+func testEnv() *env {
+	resp := testResponse
+	return &env{
+		resp:      &resp,
+		variables: map[string]string{"userName": "bjensen"},
+		locals:    map[string]interface{}{"user": map[string]interface{}{"id": "1"}},
+	}
+}
+
+func TestInner(t *testing.T) {
+	for _, test := range []struct {
+		s           string
+		open, close rune
+		expected    string
+		ok          bool
+	}{
+		{"(a)", '(', ')', "a", true},
+		{"pm.expect(a).to.eql(b)", '(', ')', "a", true},
+		{"(f(a, b), c).d", '(', ')', "f(a, b), c", true},
+		{"()", '(', ')', "", true},
+		{`("(", ')')`, '(', ')', `"(", ')'`, true},
+		{`("\")")`, '(', ')', `"\")"`, true},
+		{"function () { a; { b } }", '{', '}', " a; { b } ", true},
+		// unbalanced or missing characters.
+		{"(pm.response.code", '(', ')', "", false},
+		{"(f(a)", '(', ')', "", false},
+		{`(")`, '(', ')', "", false},
+		{"a)", '(', ')', "", false},
+		{"", '(', ')', "", false},
+	} {
+		if s, ok := inner(test.s, test.open, test.close); s != test.expected || ok != test.ok {
+			t.Errorf("%q: expected %q %v, got %q %v", test.s, test.expected, test.ok, s, ok)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, test := range []struct {
+		src        string
+		separators []rune
+		expected   []string
+	}{
+		{"a; b\nc", nil, []string{"a", "b", "c"}},
+		{"a;;\n  ;b;", nil, []string{"a", "b"}},
+		{`f("a;b"); g('c;d'); h(` + "`e;f`)", nil, []string{`f("a;b")`, `g('c;d')`, "h(`e;f`)"}},
+		{`f("a\";b")`, nil, []string{`f("a\";b")`}},
+		{"f(a;\nb); [c;d]; {e;f}", nil, []string{"f(a;\nb)", "[c;d]", "{e;f}"}},
+		{"a // b; c\nd", nil, []string{"a", "d"}},
+		{`"http://example.com"; b`, nil, []string{`"http://example.com"`, "b"}},
+		{"a, f(b, c), [d, e]", []rune{','}, []string{"a", "f(b, c)", "[d, e]"}},
+		{"a; b", []rune{','}, []string{"a; b"}},
+		{"", nil, nil},
+		// unbalanced parentheses keep the rest in one part.
+		{"f(a; b; c", nil, []string{"f(a; b; c"}},
+	} {
+		if parts := split(test.src, test.separators...); !reflect.DeepEqual(parts, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.src, test.expected, parts)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	for _, test := range []struct {
+		expr     string
+		expected string // JSON, empty if an error is expected
+	}{
+		{`"a"`, `"a"`},
+		{`'a'`, `"a"`},
+		{`201`, `201`},
+		{`-1.5`, `-1.5`},
+		{`true`, `true`},
+		{`null`, `null`},
+		{`undefined`, `null`},
+		{`["a", 1]`, `["a", 1]`},
+		{`{"a": 1}`, `{"a": 1}`},
+		{`pm.response.code`, `201`},
+		{`responseCode.code`, `201`},
+		{`pm.response.responseTime`, `150`},
+		{`responseTime`, `150`},
+		{`pm.response.json().userName`, `"bjensen"`},
+		{`JSON.parse(responseBody).id`, `"1"`},
+		{`pm.response.json().emails[0].value`, `"bjensen@example.com"`},
+		{`pm.response.json()["meta"]['resourceType']`, `"User"`},
+		{`pm.response.json().emails.length`, `1`},
+		{`pm.response.json().userName.length`, `7`},
+		{`pm.response.json().emails[1]`, `null`},
+		{`pm.response.json().unknown`, `null`},
+		{`pm.response.json().name.familyName`, `null`},
+		{`pm.response.headers.get("Content-Type")`, `"application/scim+json"`},
+		{`postman.getResponseHeader('content-type')`, `"application/scim+json"`},
+		{`pm.response.headers.get("Location")`, `null`},
+		{`pm.environment.get("userName")`, `"bjensen"`},
+		{`postman.getEnvironmentVariable('userName')`, `"bjensen"`},
+		{`pm.variables.get("unknown")`, `null`},
+		{`user.id`, `"1"`},
+		{` user ["id"] `, `"1"`},
+		// errors.
+		{`unknown.id`, ``},
+		{`pm.response.json().active.length`, ``},
+		{`pm.response.json().emails.map(e => e.value)`, ``},
+		{`pm.response.json().emails[`, ``},
+		{`pm.request.url`, ``},
+		{`1 + 1`, ``},
+	} {
+		v, err := testEnv().eval(test.expr)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", test.expr, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+			continue
+		}
+		var expected interface{}
+		if err := json.Unmarshal([]byte(test.expected), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, v) {
+			t.Errorf("%s: expected %v, got %v", test.expr, expected, v)
+		}
+	}
+
+	// the response is not available in pre-request scripts.
+	e := &env{variables: map[string]string{}, locals: map[string]interface{}{}}
+	for _, expr := range []string{"pm.response.code", "pm.response.json()", "responseBody", "responseTime", `pm.response.headers.get("Location")`} {
+		if _, err := e.eval(expr); err == nil {
+			t.Errorf("%s: expected an error without a response", expr)
+		}
+	}
+	// text of a response that is not JSON.
+	e.resp = &response{body: "not json"}
+	if _, err := e.eval("pm.response.json()"); err == nil {
+		t.Error("expected an error for a response that is not JSON")
+	}
+	if v, err := e.eval("pm.response.text()"); err != nil || v != "not json" {
+		t.Errorf("expected the text of the response, got %v %v", v, err)
+	}
+	if v, err := e.eval("responseBody.length"); err != nil || v != float64(8) {
+		t.Errorf("expected the length of the response, got %v %v", v, err)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	for _, test := range []struct {
+		matcher  string
+		actual   string // JSON
+		expected string // JSON array
+		ok       bool
+	}{
+		{"eql", `"a"`, `["a"]`, true},
+		{"eql", `{"a": [1]}`, `[{"a": [1]}]`, true},
+		{"eql", `"a"`, `["b"]`, false},
+		{"eql", `1`, `["1"]`, false},
+		{"eql", `1`, `[]`, false},
+		{"equal", `1`, `[1]`, true},
+		{"include", `"bjensen"`, `["jen"]`, true},
+		{"include", `"bjensen"`, `["Jen"]`, false},
+		{"include", `[1, 2]`, `[2]`, true},
+		{"include", `[1, 2]`, `[3]`, false},
+		{"include", `{"a": 1, "b": 2}`, `[{"a": 1}]`, true},
+		{"include", `{"a": 1, "b": 2}`, `[{"a": 2}]`, false},
+		{"include", `1`, `[1]`, false},
+		{"contain", `"abc"`, `["b"]`, true},
+		{"lengthOf", `[1, 2]`, `[2]`, true},
+		{"lengthOf", `"abc"`, `[2]`, false},
+		{"lengthOf", `1`, `[1]`, false},
+		{"above", `2`, `[1]`, true},
+		{"above", `1`, `[1]`, false},
+		{"gt", `"2"`, `[1]`, false},
+		{"below", `1`, `[2]`, true},
+		{"below", `2`, `[2]`, false},
+		{"lessThan", `1`, `[2]`, true},
+		{"oneOf", `201`, `[[200, 201]]`, true},
+		{"oneOf", `204`, `[[200, 201]]`, false},
+		{"oneOf", `200`, `[200]`, false},
+		{"property", `{"id": "1"}`, `["id"]`, true},
+		{"property", `{"id": "1"}`, `["id", "1"]`, true},
+		{"property", `{"id": "1"}`, `["id", "2"]`, false},
+		{"property", `{"id": "1"}`, `["userName"]`, false},
+		{"property", `[]`, `["id"]`, false},
+		{"a", `"a"`, `["string"]`, true},
+		{"a", `1`, `["number"]`, true},
+		{"an", `[]`, `["array"]`, true},
+		{"an", `{}`, `["object"]`, true},
+		{"a", `true`, `["boolean"]`, true},
+		{"a", `[]`, `["object"]`, false},
+		{"true", `true`, `[]`, true},
+		{"true", `"true"`, `[]`, false},
+		{"false", `false`, `[]`, true},
+		{"false", `null`, `[]`, false},
+		{"null", `null`, `[]`, true},
+		{"undefined", `0`, `[]`, false},
+		{"exist", `0`, `[]`, true},
+		{"exist", `null`, `[]`, false},
+		{"ok", `"a"`, `[]`, true},
+		{"ok", `0`, `[]`, false},
+		{"ok", `""`, `[]`, false},
+		{"empty", `[]`, `[]`, true},
+		{"empty", `""`, `[]`, true},
+		{"empty", `{"a": 1}`, `[]`, false},
+		{"empty", `null`, `[]`, false},
+	} {
+		var (
+			actual   interface{}
+			expected []interface{}
+		)
+		if err := json.Unmarshal([]byte(test.actual), &actual); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(test.expected), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if ok := matchers[test.matcher](actual, expected); ok != test.ok {
+			t.Errorf("%s %s %s: expected %v, got %v", test.actual, test.matcher, test.expected, test.ok, ok)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		script string
+		// results are the results of the tests by name: "pass", "fail" or "skip" (untranslated).
+		results map[string]string
+		ignored int
+	}{
+		{"status", `
+			pm.test("Status code is 201", function () {
+				pm.response.to.have.status(201);
+			});
+			pm.test("Status code is 200", function () {
+				pm.response.to.have.status(200);
+			});
+			pm.test("Success", () => { pm.response.to.be.success; });
+			pm.test("Ok", () => { pm.response.to.be.ok; });`,
+			map[string]string{"Status code is 201": "pass", "Status code is 200": "fail", "Success": "pass", "Ok": "fail"}, 0},
+		{"expect", `
+			pm.test('User', function () {
+				var user = pm.response.json();
+				pm.expect(user.userName).to.eql(pm.environment.get("userName"));
+				pm.expect(user.emails).to.have.lengthOf(1);
+				pm.expect(user.groups).to.be.empty;
+				pm.expect(user.active).to.be.true;
+				pm.expect(user.meta).to.have.property("resourceType", "User");
+				pm.expect(pm.response.code).to.be.oneOf([200, 201]);
+				pm.expect(user.id).to.be.a("string").and.not.empty;
+			});`,
+			map[string]string{"User": "pass"}, 0},
+		{"failing assertions", `
+			pm.test("eql", function () { pm.expect(pm.response.json().userName).to.eql("other"); });
+			pm.test("not", function () { pm.expect(pm.response.json().active).to.not.be.true; });
+			pm.test("above", function () { pm.expect(pm.response.responseTime).to.be.above(1000); });
+			pm.test("include", function () {
+				pm.expect(pm.response.headers.get("Content-Type")).to.include("application/json");
+			});
+			pm.test("unknown local", function () { pm.expect(unknown).to.be.ok; });`,
+			map[string]string{"eql": "fail", "not": "fail", "above": "fail", "include": "fail", "unknown local": "fail"}, 0},
+		{"legacy", `
+			tests["Status code is 201"] = responseCode.code === 201;
+			tests['Not 200'] = responseCode.code !== 200;
+			tests["Body"] = JSON.parse(responseBody).userName == "other";
+			tests["Untranslated"] = responseBody.has("bjensen");`,
+			map[string]string{"Status code is 201": "pass", "Not 200": "pass", "Body": "fail", "Untranslated": "skip"}, 0},
+		{"untranslated", `
+			pm.test("Matches", function () { pm.expect(pm.response.json().id).to.match(/\d+/); });
+			pm.test("Schema", function () { pm.response.to.have.jsonSchema(schema); });
+			pm.test("Partly", function () {
+				pm.expect(pm.response.code).to.eql(201);
+				console.log(pm.response.code);
+			});
+			pm.test("No function");
+			pm.test(name, function () {});
+			console.log("outside of a test");
+			pm.environment.set("id", pm.response.json().id);`,
+			map[string]string{
+				"Matches": "skip", "Schema": "skip", "Partly": "skip", `pm.test("No function")`: "skip", "name": "skip",
+			}, 1},
+		{"unbalanced expect", `pm.test("Status", function () { pm.expect(pm.response.code; }));`,
+			map[string]string{"Status": "skip"}, 0},
+		{"unbalanced arguments", `pm.test("Status", function () { pm.expect(pm.response.code).to.eql(201; }));`,
+			map[string]string{"Status": "skip"}, 0},
+		// the test is not closed, it is skipped as a whole.
+		{"unbalanced test", `pm.test("Status", function () { pm.expect(pm.response.code; });`,
+			map[string]string{`pm.test("Status", function () { pm.expect(pm.response.code; });`: "skip"}, 0},
+		{"unbalanced function", `pm.test("Status", function () { pm.response.to.have.status(201); )`,
+			map[string]string{"Status": "skip"}, 0},
+		{"unclosed test", `pm.test("Status", function () { pm.response.to.have.status(201); }`,
+			map[string]string{`pm.test("Status", function () { pm.response.to.have.status(201); }`: "skip"}, 0},
+		{"unbalanced statement", "pm.expect(pm.response.code", map[string]string{}, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			translated := translate(test.script)
+			if len(translated.ignored) != test.ignored {
+				t.Errorf("expected %d ignored statement(s), got %q", test.ignored, translated.ignored)
+			}
+
+			e := testEnv()
+			for _, stmt := range translated.statements {
+				if err := stmt(e); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			results := make(map[string]string)
+			for _, test := range translated.tests {
+				result := "pass"
+				if test.untranslated != "" {
+					result = "skip"
+				}
+				for _, stmt := range test.statements {
+					if result == "pass" && stmt(e) != nil {
+						result = "fail"
+					}
+				}
+				results[test.name] = result
+			}
+			if !reflect.DeepEqual(test.results, results) {
+				t.Errorf("expected %v, got %v", test.results, results)
+			}
+		})
+	}
+
+	// statements outside of the tests set variables and locals for the tests that follow.
+	translated := translate(`
+		var id = pm.response.json().id;
+		pm.environment.set("id", id);
+		postman.setEnvironmentVariable('userName', pm.response.json()["userName"]);`)
+	e := testEnv()
+	for _, stmt := range translated.statements {
+		if err := stmt(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(translated.statements) != 3 || e.variables["id"] != "1" || e.variables["userName"] != "bjensen" {
+		t.Errorf("expected the variables to be set, got %v", e.variables)
+	}
+}
@@ -0,0 +1,215 @@
+package postman
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// DefaultBaseURL is the prefix of the URLs in the SCIM validator collection of Azure AD, it refers to the base URL of
+// the suite.
+const DefaultBaseURL = "{{Server}}{{Port}}/{{Api}}"
+
+// DefaultPathCorrections are the wrong paths in the SCIM validator collection of Azure AD, with the paths of the SCIM
+// specification. Paths are case sensitive.
+var DefaultPathCorrections = map[string]string{
+	"/users":                "/Users",
+	"/groups":               "/Groups",
+	"/serviceConfiguration": "/ServiceProviderConfig",
+	"/Users/":               "/Users",
+	"/Groups/":              "/Groups",
+}
+
+// Suite runs the requests of a collection as subtests, with a subtest for every test of their test scripts (e.g.
+// "Users/Post_User/Status_code_is_201"). The base URL, middleware and retries of the suite are used for every request.
+type Suite struct {
+	util.Suite
+	collection      *Collection
+	variables       map[string]string
+	baseURL         string
+	pathCorrections map[string]string
+}
+
+// NewSuite returns a suite that runs the requests of the given collection.
+func NewSuite(collection *Collection) *Suite {
+	return &Suite{
+		collection:      collection,
+		variables:       make(map[string]string),
+		baseURL:         DefaultBaseURL,
+		pathCorrections: DefaultPathCorrections,
+	}
+}
+
+// SetVariable sets the value of a variable, it overrides the value of the collection variable.
+func (s *Suite) SetVariable(name, value string) {
+	s.variables[name] = value
+}
+
+// SetBaseURL sets the prefix of the URLs in the collection that refers to the base URL of the suite, e.g.
+// "{{baseUrl}}".
+func (s *Suite) SetBaseURL(prefix string) {
+	s.baseURL = prefix
+}
+
+// SetPathCorrections sets the paths that get corrected. A path is corrected if it equals a wrong path, or if it starts
+// with it followed by a "/".
+func (s *Suite) SetPathCorrections(corrections map[string]string) {
+	s.pathCorrections = corrections
+}
+
+// TestCollection runs the requests of the collection after one another, folders become subtests as well.
+func (s *Suite) TestCollection() {
+	variables := make(map[string]string)
+	for _, v := range s.collection.Variables {
+		variables[v.Key] = string(v.Value)
+	}
+	for k, v := range s.variables {
+		variables[k] = v
+	}
+	s.items(s.collection.Items, variables, s.collection.Events)
+}
+
+// items runs the given items with the scripts of their parents.
+func (s *Suite) items(items []Item, variables map[string]string, events []Event) {
+	for _, item := range items {
+		item := item
+		s.Run(item.Name, func() {
+			events := append(append([]Event(nil), events...), item.Events...)
+			if item.Request == nil {
+				s.items(item.Items, variables, events)
+				return
+			}
+			s.request(*item.Request, variables, events)
+		})
+	}
+}
+
+// request runs the pre-request scripts, sends the request and runs the tests.
+func (s *Suite) request(r Request, variables map[string]string, events []Event) {
+	e := &env{variables: variables, locals: make(map[string]interface{})}
+	for _, stmt := range translate(script(events, "prerequest")).statements {
+		if err := stmt(e); err != nil {
+			s.T().Logf("pre-request script: %v", err)
+		}
+	}
+
+	target, missing := resolve(strings.TrimPrefix(r.URL.Raw, s.baseURL), variables)
+	var body string
+	if r.Body != nil && r.Body.Mode == "raw" {
+		var missingBody []string
+		body, missingBody = resolve(r.Body.Raw, variables)
+		missing = append(missing, missingBody...)
+	}
+	if len(missing) != 0 {
+		s.T().Skipf("variables without a value: %s", strings.Join(missing, ", "))
+	}
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	var req *http.Request
+	if strings.Contains(target, "://") {
+		var err error
+		req, err = http.NewRequest(r.Method, target, reader)
+		s.Require().NoError(err)
+	} else {
+		req = s.NewRequest(r.Method, s.correct(target), reader)
+	}
+	for _, h := range r.Header {
+		if h.Disabled {
+			continue
+		}
+		// headers without a value for their variables (e.g. "Authorization: Bearer {{token}}") are left out, the
+		// middleware can set them.
+		if value, missing := resolve(h.Value, variables); len(missing) == 0 {
+			req.Header.Set(h.Key, value)
+		}
+	}
+
+	start := time.Now()
+	resp := s.Do(req)
+	raw, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	s.Require().NoError(err)
+	e.resp = &response{
+		status:  resp.StatusCode,
+		header:  resp.Header,
+		body:    string(raw),
+		latency: time.Since(start),
+	}
+
+	t := translate(script(events, "test"))
+	for _, stmt := range t.ignored {
+		s.T().Logf("untranslated statement: %s", stmt)
+	}
+	for _, stmt := range t.statements {
+		if err := stmt(e); err != nil {
+			s.T().Logf("test script: %v", err)
+		}
+	}
+	for _, test := range t.tests {
+		test := test
+		s.Run(test.name, func() {
+			if test.untranslated != "" {
+				s.T().Skipf("untranslated statement: %s", test.untranslated)
+			}
+			for _, stmt := range test.statements {
+				if err := stmt(e); err != nil {
+					s.Fail(err.Error())
+					return
+				}
+			}
+		})
+	}
+}
+
+// correct corrects the path of the given target, the query is left untouched.
+func (s *Suite) correct(target string) string {
+	path, query := target, ""
+	if i := strings.Index(target, "?"); i != -1 {
+		path, query = target[:i], target[i:]
+	}
+	for wrong, correct := range s.pathCorrections {
+		switch {
+		case path == wrong:
+			return correct + query
+		case !strings.HasSuffix(wrong, "/") && strings.HasPrefix(path, wrong+"/"):
+			return correct + path[len(wrong):] + query
+		}
+	}
+	return target
+}
+
+// resolve replaces the placeholders in the template with the values of the variables and dynamic variables. It returns
+// the names of the placeholders that could not be resolved.
+func resolve(template string, variables map[string]string) (string, []string) {
+	return runner.Resolve(template, variables, dynamic)
+}
+
+// dynamic returns a value of the Postman dynamic variable with the given name.
+func dynamic(name string) (string, bool) {
+	switch name {
+	case "$guid", "$randomUUID":
+		return runner.UUID(), true
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case "$isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), true
+	case "$randomInt":
+		return runner.RandomInt(1001), true
+	case "$randomAlphaNumeric":
+		return runner.RandomString(1), true
+	case "$randomUserName", "$randomFirstName", "$randomLastName", "$randomWord":
+		return "user" + runner.RandomString(8), true
+	case "$randomEmail", "$randomExampleEmail":
+		return "user" + runner.RandomString(8) + "@example.com", true
+	}
+	return "", false
+}
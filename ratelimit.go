@@ -0,0 +1,58 @@
+package suite
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// RFC: https://tools.ietf.org/html/rfc6585#section-4
+
+// maxRetryAfter is the longest delay of a Retry-After header that TestRateLimit waits for.
+const maxRetryAfter = time.Minute
+
+func (suite *SCIMTestSuite) TestRateLimit() {
+	ceiling := suite.rateLimitCeiling
+	if ceiling == 0 {
+		ceiling = defaultRateLimitCeiling
+	}
+	if ceiling < 0 {
+		suite.T().Skip("rate limit tests are disabled")
+	}
+
+	resp, sent := suite.Burst("/Users?count=1", ceiling)
+	if resp == nil {
+		suite.T().Skipf("no 429 (Too Many Requests) after %d requests", sent)
+	}
+	suite.T().Logf("got a 429 (Too Many Requests) after %d requests", sent)
+
+	var scimError struct {
+		Schemas []string
+		Status  string
+	}
+	err := json.NewDecoder(resp.Body).Decode(&scimError)
+	_ = resp.Body.Close()
+	retryAfter := resp.Header.Get("Retry-After")
+
+	suite.Run("ErrorResponse", func() {
+		suite.Should().NoError(err, "the 429 response is not JSON")
+		suite.Should().Contains(scimError.Schemas, "urn:ietf:params:scim:api:messages:2.0:Error")
+		suite.Should().Equal("429", scimError.Status)
+	})
+
+	suite.Run("RetryAfter", func() {
+		suite.Should().NotEmpty(retryAfter, "the 429 response has no Retry-After header")
+	})
+
+	suite.Run("SucceedsAfterWaiting", func() {
+		delay := util.RetryAfter(retryAfter, time.Second)
+		if delay > maxRetryAfter {
+			suite.T().Skipf("Retry-After of %s is too long to wait for", delay)
+		}
+		time.Sleep(delay)
+		resp := suite.Get("/Users?count=1")
+		suite.Equal(http.StatusOK, resp.StatusCode)
+	})
+}
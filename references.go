@@ -0,0 +1,125 @@
+package suite
+
+import "github.com/di-wu/scim-test-suite/report"
+
+// References links the tests of the SCIMTestSuite to the requirements of the RFCs they verify.
+var References = report.References{
+	"TestSchemas": {
+		Spec: "RFC7643", Section: "7", Level: report.MUST,
+		Requirement: "Schema definitions conform to the schema of the schema definitions.",
+	},
+	"TestAttributes": {
+		Spec: "RFC7643", Section: "2.1", Level: report.MUST,
+		Requirement: "Attribute names conform to the ATTRNAME ABNF rules.",
+	},
+
+	"TestCreateUser/Created": {
+		Spec: "RFC7644", Section: "3.3", Level: report.MUST,
+		Requirement: "A successfully created resource results in 201 (Created).",
+	},
+	"TestCreateUser/Location": {
+		Spec: "RFC7644", Section: "3.3", Level: report.MUST,
+		Requirement: "The URI of the created resource is included in the Location header.",
+	},
+	"TestCreateUser/UserNameUniqueness": {
+		Spec: "RFC7643", Section: "4.1.1", Level: report.MUST,
+		Requirement: "User names are unique and case insensitive, a duplicate results in 409 (Conflict).",
+	},
+
+	"TestConcurrency/UserNameUniqueness": {
+		Spec: "RFC7643", Section: "4.1.1", Level: report.MUST,
+		Requirement: "Of concurrent creates with the same user name exactly one succeeds, the others result in 409 (Conflict).",
+	},
+	"TestConcurrency/GroupMembers": {
+		Spec: "RFC7644", Section: "3.5.2", Level: report.MUST,
+		Requirement: "Concurrent PATCH requests that add members to the same group do not lose any updates.",
+	},
+	"TestConcurrency/IfMatch": {
+		Spec: "RFC7644", Section: "3.14", Level: report.MUST,
+		Requirement: "Of concurrent PUT requests with the same If-Match version exactly one succeeds, the others result in 412 (Precondition Failed).",
+	},
+
+	"TestGroupMembership/UnknownMember": {
+		Spec: "RFC7643", Section: "4.2", Level: report.SHOULD,
+		Requirement: "A member that does not refer to an existing user or group results in 400 (Bad Request).",
+	},
+	"TestGroupMembership/MemberType": {
+		Spec: "RFC7643", Section: "4.2", Level: report.MUST,
+		Requirement: "The type of a member is the type of the resource it refers to, \"User\" or \"Group\".",
+	},
+	"TestGroupMembership/Reference": {
+		Spec: "RFC7643", Section: "4.2", Level: report.MUST,
+		Requirement: "The $ref of a member is the URI of the resource it refers to.",
+	},
+	"TestGroupMembership/UserGroups": {
+		Spec: "RFC7643", Section: "4.1.2", Level: report.SHOULD,
+		Requirement: "The groups of a user list the groups it is a member of.",
+	},
+	"TestGroupMembership/UserGroupsReadOnly": {
+		Spec: "RFC7643", Section: "4.1.2", Level: report.MUST,
+		Requirement: "Group membership changes are applied via the Group resource, the groups of a user are read-only.",
+	},
+	"TestGroupMembership/NestedGroups": {
+		Spec: "RFC7643", Section: "4.2", Level: report.MUST,
+		Requirement: "A group that is added as a member either gets the type \"Group\" or is rejected.",
+	},
+	"TestGroupMembership/DeleteRemovesMembership": {
+		Spec: "RFC7644", Section: "3.6", Level: report.SHOULD,
+		Requirement: "A deleted user is removed from the members of the groups it belonged to.",
+	},
+
+	"TestLargeGroup/Post": {
+		Spec: "RFC7644", Section: "3.3", Level: report.MUST,
+		Requirement: "A group with thousands of members can be created with a single POST.",
+	},
+	"TestLargeGroup/PatchAdd": {
+		Spec: "RFC7644", Section: "3.5.2.1", Level: report.MUST,
+		Requirement: "Thousands of members can be added to a group with batched PATCH add operations.",
+	},
+	"TestLargeGroup/PatchResponse": {
+		Spec: "RFC7644", Section: "3.5.2", Level: report.SHOULD,
+		Requirement: "The response of a PATCH does not contain all members of a large group unless requested.",
+	},
+	"TestLargeGroup/PatchAttributes": {
+		Spec: "RFC7644", Section: "3.9", Level: report.SHOULD,
+		Requirement: "The response of a PATCH contains the members that are requested with the attributes parameter.",
+	},
+	"TestLargeGroup/RemoveMembers": {
+		Spec: "RFC7644", Section: "3.5.2.2", Level: report.MUST,
+		Requirement: "Members are removed with a value filter on the path of a PATCH remove operation.",
+	},
+	"TestLargeGroup/ExcludedAttributes": {
+		Spec: "RFC7644", Section: "3.9", Level: report.SHOULD,
+		Requirement: "The members of a group are left out with the excludedAttributes parameter.",
+	},
+
+	"TestRateLimit/ErrorResponse": {
+		Spec: "RFC7644", Section: "3.12", Level: report.SHOULD,
+		Requirement: "A rate limited request results in a SCIM error response with status \"429\".",
+	},
+	"TestRateLimit/RetryAfter": {
+		Spec: "RFC6585", Section: "4", Level: report.SHOULD,
+		Requirement: "A 429 (Too Many Requests) response includes a Retry-After header with the time to wait.",
+	},
+	"TestRateLimit/SucceedsAfterWaiting": {
+		Spec: "RFC6585", Section: "4", Level: report.MUST,
+		Requirement: "Requests succeed again after waiting for the delay of the Retry-After header.",
+	},
+
+	"TestServiceProviderConfigurationEndpoints/ServiceProviderConfig": {
+		Spec: "RFC7644", Section: "4", Level: report.MUST,
+		Requirement: "GET /ServiceProviderConfig returns a JSON object with the ServiceProviderConfig schema.",
+	},
+	"TestServiceProviderConfigurationEndpoints/Schemas": {
+		Spec: "RFC7644", Section: "4", Level: report.MUST,
+		Requirement: "GET /Schemas returns all supported schemas in ListResponse format.",
+	},
+	"TestServiceProviderConfigurationEndpoints/ResourceTypes": {
+		Spec: "RFC7644", Section: "4", Level: report.MUST,
+		Requirement: "GET /ResourceTypes returns all supported resource types.",
+	},
+	"TestServiceProviderConfigurationEndpoints/ForbiddenFilter": {
+		Spec: "RFC7644", Section: "4", Level: report.SHOULD,
+		Requirement: "A filter on a service provider configuration endpoint results in 403 (Forbidden).",
+	},
+}
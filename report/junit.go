@@ -0,0 +1,127 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report in JUnit XML format to the given writer. Every top level test (i.e. the test function
+// that runs the suite) becomes a test suite, all its subtests become test cases.
+func (r Report) WriteJUnit(w io.Writer) error {
+	var (
+		suites junitTestSuites
+		index  = make(map[string]int)
+	)
+	for _, test := range r.Tests {
+		parts := strings.SplitN(test.Name, "/", 2)
+		if len(parts) == 1 {
+			index[test.Package+"#"+test.Name] = len(suites.Suites)
+			suites.Suites = append(suites.Suites, junitTestSuite{
+				Name: fmt.Sprintf("%s/%s", test.Package, test.Name),
+				Time: seconds(test.Duration),
+			})
+			continue
+		}
+
+		i, ok := index[test.Package+"#"+parts[0]]
+		if !ok {
+			continue
+		}
+
+		testCase := junitTestCase{
+			Name:      parts[1],
+			ClassName: fmt.Sprintf("%s.%s", test.Package, parts[0]),
+			Time:      seconds(test.Duration),
+		}
+		if ref := test.Reference; ref != nil {
+			testCase.Properties = []junitProperty{
+				{Name: "spec", Value: ref.Spec},
+				{Name: "section", Value: ref.Section},
+			}
+		}
+
+		if len(test.Warnings) != 0 {
+			testCase.SystemOut = strings.Join(test.Warnings, "\n\n")
+		}
+
+		suite := &suites.Suites[i]
+		suite.Tests++
+		switch test.Status {
+		case StatusFail:
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: summary(test.Messages),
+				Body:    strings.Join(test.Messages, "\n\n"),
+			}
+		case StatusSkip:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{
+				Message: strings.Join(test.Messages, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	if err := e.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// summary returns the first testify error of the given messages, e.g. "Not equal:".
+func summary(messages []string) string {
+	for _, message := range messages {
+		for _, line := range strings.Split(message, "\n") {
+			if strings.HasPrefix(line, "Error:") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "Error:"))
+			}
+		}
+	}
+	return "Failed"
+}
+
+func seconds(d float64) string {
+	return fmt.Sprintf("%.3f", d)
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Requirement is a single row of the compliance matrix, it combines the results of all tests that verify the same
+// requirement.
+type Requirement struct {
+	Reference
+	Status Status
+	Tests  []Test
+}
+
+// Matrix groups the tests of the report by the requirement they verify. Tests without a reference are left out.
+// A requirement fails if one of its tests fails, it is skipped if all its tests are skipped. A passed requirement gets a
+// warning if one of its tests logged a SHOULD or MAY deviation.
+func (r Report) Matrix() []Requirement {
+	var (
+		matrix []Requirement
+		index  = make(map[Reference]int)
+	)
+	for _, test := range r.Tests {
+		if test.Reference == nil {
+			continue
+		}
+
+		i, ok := index[*test.Reference]
+		if !ok {
+			i = len(matrix)
+			index[*test.Reference] = i
+			matrix = append(matrix, Requirement{
+				Reference: *test.Reference,
+				Status:    StatusSkip,
+			})
+		}
+
+		requirement := &matrix[i]
+		requirement.Tests = append(requirement.Tests, test)
+		switch test.Status {
+		case StatusFail:
+			requirement.Status = StatusFail
+		case StatusPass:
+			if requirement.Status == StatusSkip {
+				requirement.Status = StatusPass
+			}
+			if len(test.Warnings) != 0 && requirement.Status != StatusFail {
+				requirement.Status = StatusWarn
+			}
+		}
+	}
+
+	sort.SliceStable(matrix, func(i, j int) bool {
+		a, b := matrix[i], matrix[j]
+		if a.Spec != b.Spec {
+			return a.Spec < b.Spec
+		}
+		return lessSection(a.Section, b.Section)
+	})
+	return matrix
+}
+
+// lessSection compares sections numerically, e.g. "3.4.2" < "3.12".
+func lessSection(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		ai, errA := strconv.Atoi(as[i])
+		bi, errB := strconv.Atoi(bs[i])
+		if errA != nil || errB != nil {
+			return as[i] < bs[i]
+		}
+		return ai < bi
+	}
+	return len(as) < len(bs)
+}
+
+// Failed returns the names of the failed tests that verify the requirement.
+func (r Requirement) Failed() []string {
+	var names []string
+	for _, test := range r.Tests {
+		if test.Status == StatusFail {
+			names = append(names, test.Name)
+		}
+	}
+	return names
+}
+
+// WriteMarkdown writes the compliance matrix of the report as a Markdown table to the given writer.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	if _, err := fmt.Fprint(w, ""+
+		"| Spec | Section | Level | Requirement | Status | Failed Tests |\n"+
+		"| ---- | ------- | ----- | ----------- | ------ | ------------ |\n",
+	); err != nil {
+		return err
+	}
+	for _, requirement := range r.Matrix() {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n",
+			requirement.Spec,
+			requirement.Section,
+			requirement.Level,
+			escapeMarkdown(requirement.Requirement),
+			requirement.Status,
+			escapeMarkdown(strings.Join(requirement.Failed(), ", ")),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+var matrixTemplate = template.Must(template.New("matrix").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>SCIM Compliance Matrix</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.pass { background: #dff0d8; }
+.fail { background: #f2dede; }
+.skip { background: #eeeeee; }
+.warn { background: #fcf8e3; }
+</style>
+</head>
+<body>
+<table>
+<tr><th>Spec</th><th>Section</th><th>Level</th><th>Requirement</th><th>Status</th><th>Failed Tests</th></tr>
+{{- range . }}
+<tr class="{{ .Status }}"><td>{{ .Spec }}</td><td>{{ .Section }}</td><td>{{ .Level }}</td><td>{{ .Requirement }}</td><td>{{ .Status }}</td><td>{{ range .Failed }}{{ . }}<br>{{ end }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the compliance matrix of the report as an HTML page to the given writer.
+func (r Report) WriteHTML(w io.Writer) error {
+	return matrixTemplate.Execute(w, r.Matrix())
+}
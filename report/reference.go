@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the requirement level of a specification (RFC2119).
+type Level string
+
+const (
+	MUST   Level = "MUST"
+	SHOULD Level = "SHOULD"
+	MAY    Level = "MAY"
+)
+
+// Reference links a test to the part of a specification it verifies.
+type Reference struct {
+	// Spec is the specification, e.g. "RFC7644" or "Okta SCIM 2.0 Spec Test".
+	Spec string `json:"spec"`
+	// Section is the section of the specification or the assertion in the spec test, e.g. "4" or "Assertion 3".
+	Section string `json:"section"`
+	// Level is the requirement level of the verified requirement. It is empty if the specification does not specify
+	// requirement levels (e.g. IdP spec tests).
+	Level Level `json:"level,omitempty"`
+	// Requirement is a short description of the verified requirement.
+	Requirement string `json:"requirement,omitempty"`
+}
+
+// References maps test names on the reference they belong to. The names are relative to the test suite, e.g.
+// "TestGetFirstUser/ItemsPerPageIsNumber", or to the top level test that runs the suite if they are scoped.
+type References map[string]Reference
+
+// Scope prefixes the test names with the (top level) test that runs the suite, e.g. "TestSCIM". Suites with tests of
+// the same name (e.g. "TestCreateUser") have to be scoped to be merged.
+func (refs References) Scope(test string) References {
+	scoped := make(References, len(refs))
+	for name, ref := range refs {
+		scoped[test+"/"+name] = ref
+	}
+	return scoped
+}
+
+// Merge returns the union of the given references. It returns an error if a test name occurs more than once.
+func Merge(refs ...References) (References, error) {
+	merged := make(References)
+	for _, r := range refs {
+		for name, ref := range r {
+			if _, ok := merged[name]; ok {
+				return nil, fmt.Errorf("duplicate reference for test %q, scope the references of the suites", name)
+			}
+			merged[name] = ref
+		}
+	}
+	return merged, nil
+}
+
+// Index is a set of references with normalized test names, to look up the references of many tests.
+type Index map[string]Reference
+
+// Index returns the references with normalized test names.
+func (refs References) Index() Index {
+	index := make(Index, len(refs))
+	for k, v := range refs {
+		index[normalize(k)] = v
+	}
+	return index
+}
+
+// Lookup returns the reference of the test with the given (full) name. Subtests inherit the reference of their parent
+// if they do not have one of their own. The first part of the name is the test function that runs the suite, which is
+// not known in advance for references that are not scoped, so it gets ignored if no match is found.
+func (index Index) Lookup(name string) (Reference, bool) {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		// subtests with the same name get a unique suffix, e.g. "Get_group1_by_id#01".
+		if j := strings.LastIndex(part, "#"); j != -1 {
+			parts[i] = part[:j]
+		}
+	}
+	for _, start := range []int{0, 1} {
+		for end := len(parts); start < end; end-- {
+			if ref, ok := index[strings.Join(parts[start:end], "/")]; ok {
+				return ref, true
+			}
+		}
+	}
+	return Reference{}, false
+}
+
+// normalize rewrites the test name the same way the testing package does, e.g. spaces are replaced with underscores.
+func normalize(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a single (sub)test.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+	// StatusWarn is only used in the compliance matrix, it indicates that a requirement passed but that some of its
+	// SHOULD or MAY requirements were not met.
+	StatusWarn Status = "warn"
+)
+
+// WarningPrefix marks the messages of SHOULD and MAY deviations that do not fail the test, see util.Suite.Should.
+const WarningPrefix = "WARNING"
+
+// Report is the machine-readable result of a test run.
+type Report struct {
+	Tests []Test `json:"tests"`
+}
+
+// Test is the result of a single (sub)test, e.g. "TestIdP/TestGetFirstUser/ItemsPerPageIsNumber".
+type Test struct {
+	Package   string     `json:"package"`
+	Name      string     `json:"name"`
+	Status    Status     `json:"status"`
+	Duration  float64    `json:"duration"`           // in seconds
+	Messages  []string   `json:"messages,omitempty"` // only for failed or skipped tests
+	Warnings  []string   `json:"warnings,omitempty"`
+	Reference *Reference `json:"reference,omitempty"`
+
+	output []string
+}
+
+// event is a single line of the output of "go test -json" (cmd/test2json).
+type event struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Parse reads the output of "go test -json" and maps every (sub)test on its result. The given references are used
+// to link the tests to the specification they verify.
+func Parse(r io.Reader, refs References) (Report, error) {
+	var (
+		tests   = make([]*Test, 0)
+		index   = make(map[string]*Test)
+		lookups = refs.Index()
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			// not an event, e.g. build output.
+			continue
+		}
+
+		var e event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return Report{}, err
+		}
+		if e.Test == "" {
+			// package level event.
+			continue
+		}
+
+		key := e.Package + "#" + e.Test
+		test, ok := index[key]
+		if !ok {
+			test = &Test{
+				Package: e.Package,
+				Name:    e.Test,
+			}
+			if ref, ok := lookups.Lookup(e.Test); ok {
+				test.Reference = &ref
+			}
+			index[key] = test
+			tests = append(tests, test)
+		}
+
+		switch e.Action {
+		case "output":
+			test.output = append(test.output, e.Output)
+		case "pass":
+			test.Status, test.Duration = StatusPass, e.Elapsed
+			_, test.Warnings = messages(test.output)
+		case "fail":
+			test.Status, test.Duration = StatusFail, e.Elapsed
+			test.Messages, test.Warnings = messages(test.output)
+		case "skip":
+			test.Status, test.Duration = StatusSkip, e.Elapsed
+			test.Messages, test.Warnings = messages(test.output)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	for _, test := range tests {
+		report.Tests = append(report.Tests, *test)
+	}
+	return report, nil
+}
+
+// location matches the start of a message logged by a test, e.g. "    okta.go:25: ".
+var location = regexp.MustCompile(`^\s*[\w.-]+\.go:\d+: `)
+
+// messages extracts the messages from the output of a test, leaving out the lines that are added by the test runner.
+// Warnings of SHOULD and MAY deviations are returned separately.
+func messages(output []string) ([]string, []string) {
+	var (
+		msgs, warnings []string
+		msg            strings.Builder
+	)
+	flush := func() {
+		if msg.Len() != 0 {
+			m := strings.TrimRight(msg.String(), "\n")
+			if strings.Contains(strings.SplitN(m, "\n", 2)[0], WarningPrefix+" (") {
+				warnings = append(warnings, m)
+			} else {
+				msgs = append(msgs, m)
+			}
+			msg.Reset()
+		}
+	}
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			flush()
+			continue
+		}
+		if location.MatchString(line) {
+			// a new message, the lines that follow belong to it (e.g. the testify error trace).
+			flush()
+		}
+		if trimmed != "" {
+			msg.WriteString(trimmed)
+			msg.WriteString("\n")
+		}
+	}
+	flush()
+	return msgs, warnings
+}
+
+// WriteJSON writes the report in JSON format to the given writer.
+func (r Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(r)
+}
@@ -0,0 +1,172 @@
+package runscope
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+// response is the response of a request step, with the values that the sources of assertions and variables refer to.
+type response struct {
+	status  int
+	header  http.Header
+	body    []byte
+	json    interface{}
+	latency time.Duration
+}
+
+// source returns the value of the given source and property of the response.
+func (r response) source(source, property string) (interface{}, error) {
+	switch source {
+	case "response_status":
+		return json.Number(strconv.Itoa(r.status)), nil
+	case "response_headers":
+		if values, ok := r.header[http.CanonicalHeaderKey(property)]; ok {
+			return strings.Join(values, ", "), nil
+		}
+		return nil, nil
+	case "response_text":
+		return string(r.body), nil
+	case "response_json":
+		if r.json == nil {
+			return nil, fmt.Errorf("the response is not JSON")
+		}
+		return lookup(r.json, property), nil
+	case "response_time":
+		return json.Number(strconv.FormatInt(r.latency.Milliseconds(), 10)), nil
+	case "response_size":
+		return json.Number(strconv.Itoa(len(r.body))), nil
+	default:
+		return nil, fmt.Errorf("unsupported source: %q", source)
+	}
+}
+
+// index matches the index of an array in a property, e.g. "Resources[0]".
+var index = regexp.MustCompile(`\[(\d+)]`)
+
+// lookup returns the value of the property, e.g. "Resources[0].name.familyName", or nil if it does not exist. An
+// empty property refers to the value itself.
+func lookup(v interface{}, property string) interface{} {
+	property = index.ReplaceAllString(property, ".$1")
+	for _, key := range strings.Split(property, ".") {
+		if key == "" {
+			continue
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			v = value[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(value) {
+				return nil
+			}
+			v = value[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// compare evaluates the comparison of the actual value with the expected value. It returns whether the comparison
+// holds, or an error if the comparison is not supported.
+func compare(comparison string, actual interface{}, expected string) (bool, error) {
+	switch comparison {
+	case "equal":
+		return runner.Stringify(actual) == expected, nil
+	case "not_equal":
+		return runner.Stringify(actual) != expected, nil
+	case "empty":
+		return empty(actual), nil
+	case "not_empty":
+		return !empty(actual), nil
+	case "contains":
+		return contains(actual, expected), nil
+	case "does_not_contain":
+		return !contains(actual, expected), nil
+	case "is_null":
+		return actual == nil, nil
+	case "is_a_number":
+		_, ok := actual.(json.Number)
+		return ok, nil
+	case "has_key":
+		m, ok := actual.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		_, ok = m[expected]
+		return ok, nil
+	case "has_value":
+		return hasValue(actual, expected), nil
+	case "equal_number", "is_less_than", "is_less_than_or_equal", "is_greater_than", "is_greater_than_or_equal":
+		a, err := strconv.ParseFloat(runner.Stringify(actual), 64)
+		if err != nil {
+			return false, nil
+		}
+		e, err := strconv.ParseFloat(expected, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number: %q", expected)
+		}
+		switch comparison {
+		case "equal_number":
+			return a == e, nil
+		case "is_less_than":
+			return a < e, nil
+		case "is_less_than_or_equal":
+			return a <= e, nil
+		case "is_greater_than":
+			return a > e, nil
+		default:
+			return a >= e, nil
+		}
+	default:
+		return false, fmt.Errorf("unsupported comparison: %q", comparison)
+	}
+}
+
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(v); v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// contains returns whether the string contains the expected value, or whether one of the values of an array equals
+// it.
+func contains(v interface{}, expected string) bool {
+	if values, ok := v.([]interface{}); ok {
+		for _, value := range values {
+			if runner.Stringify(value) == expected {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(runner.Stringify(v), expected)
+}
+
+// hasValue returns whether one of the values of an array or object equals the expected value.
+func hasValue(v interface{}, expected string) bool {
+	switch v := v.(type) {
+	case []interface{}:
+		return contains(v, expected)
+	case map[string]interface{}:
+		for _, value := range v {
+			if runner.Stringify(value) == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package runscope
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+func decode(t *testing.T, raw string) interface{} {
+	t.Helper()
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestLookup(t *testing.T) {
+	v := decode(t, `{
+		"totalResults": 2,
+		"Resources": [
+			{"id": "1", "name": {"familyName": "Jensen"}, "emails": [{"value": "a@example.com"}]},
+			{"id": "2"}
+		]
+	}`)
+	for _, test := range []struct {
+		property string
+		expected string // JSON
+	}{
+		{"", `{"totalResults": 2, "Resources": [{"id": "1", "name": {"familyName": "Jensen"}, "emails": [{"value": "a@example.com"}]}, {"id": "2"}]}`},
+		{"totalResults", `2`},
+		{"Resources[0].id", `"1"`},
+		{"Resources.1.id", `"2"`},
+		{"Resources[0].name.familyName", `"Jensen"`},
+		{"Resources[0].emails[0].value", `"a@example.com"`},
+		{"Resources[1]", `{"id": "2"}`},
+		// missing properties are nil.
+		{"Resources[2].id", `null`},
+		{"Resources[-1]", `null`},
+		{"Resources.first", `null`},
+		{"Resources[1].name.familyName", `null`},
+		{"totalResults.value", `null`},
+		{"unknown", `null`},
+	} {
+		if actual, expected := runner.Stringify(lookup(v, test.property)), runner.Stringify(decode(t, test.expected)); actual != expected {
+			t.Errorf("%q: expected %s, got %s", test.property, expected, actual)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, test := range []struct {
+		comparison string
+		actual     string // JSON
+		expected   string
+		ok         bool
+	}{
+		{"equal", `"bjensen"`, "bjensen", true},
+		{"equal", `"bjensen"`, "BJensen", false},
+		{"equal", `201`, "201", true},
+		{"equal", `true`, "true", true},
+		{"equal", `null`, "", true},
+		{"equal", `{"a": 1}`, `{"a":1}`, true},
+		{"not_equal", `"bjensen"`, "other", true},
+		{"not_equal", `201`, "201", false},
+		{"empty", `""`, "", true},
+		{"empty", `[]`, "", true},
+		{"empty", `{}`, "", true},
+		{"empty", `null`, "", true},
+		{"empty", `0`, "", false},
+		{"empty", `"a"`, "", false},
+		{"not_empty", `[1]`, "", true},
+		{"not_empty", `""`, "", false},
+		{"contains", `"application/scim+json; charset=utf-8"`, "scim+json", true},
+		{"contains", `"application/json"`, "scim", false},
+		{"contains", `["a", "b"]`, "b", true},
+		{"contains", `["ab"]`, "a", false},
+		{"does_not_contain", `"application/json"`, "scim", true},
+		{"does_not_contain", `["a", "b"]`, "a", false},
+		{"is_null", `null`, "", true},
+		{"is_null", `""`, "", false},
+		{"is_a_number", `1.5`, "", true},
+		{"is_a_number", `"1"`, "", false},
+		{"has_key", `{"id": "1"}`, "id", true},
+		{"has_key", `{"id": "1"}`, "ID", false},
+		{"has_key", `["id"]`, "id", false},
+		{"has_value", `{"id": "1"}`, "1", true},
+		{"has_value", `{"id": "1"}`, "id", false},
+		{"has_value", `["a", 2]`, "2", true},
+		{"has_value", `"a"`, "a", false},
+		{"equal_number", `2`, "2.0", true},
+		{"equal_number", `"2"`, "2", true},
+		{"equal_number", `2`, "3", false},
+		{"is_less_than", `1`, "2", true},
+		{"is_less_than", `2`, "2", false},
+		{"is_less_than_or_equal", `2`, "2", true},
+		{"is_less_than_or_equal", `3`, "2", false},
+		{"is_greater_than", `3`, "2", true},
+		{"is_greater_than", `2`, "2", false},
+		{"is_greater_than_or_equal", `2`, "2", true},
+		{"is_greater_than_or_equal", `1`, "2", false},
+		// values that are not a number do not hold.
+		{"is_less_than", `"abc"`, "2", false},
+		{"is_greater_than", `null`, "2", false},
+	} {
+		ok, err := compare(test.comparison, decode(t, test.actual), test.expected)
+		if err != nil {
+			t.Errorf("%s %s %q: unexpected error: %v", test.actual, test.comparison, test.expected, err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("%s %s %q: expected %v, got %v", test.actual, test.comparison, test.expected, test.ok, ok)
+		}
+	}
+
+	for _, test := range []struct{ comparison, expected string }{
+		{"matches", "a"},
+		{"is_less_than", "two"},
+		{"equal_number", ""},
+	} {
+		if _, err := compare(test.comparison, json.Number("1"), test.expected); err == nil {
+			t.Errorf("%s %q: expected an error", test.comparison, test.expected)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	r := response{
+		status:  http.StatusCreated,
+		header:  http.Header{"Content-Type": {"application/scim+json"}},
+		body:    []byte(`{"id": "1"}`),
+		json:    decode(t, `{"id": "1"}`),
+		latency: 1500 * time.Millisecond,
+	}
+	for _, test := range []struct {
+		source, property string
+		expected         string
+	}{
+		{"response_status", "", "201"},
+		{"response_headers", "content-type", "application/scim+json"},
+		{"response_headers", "Location", ""},
+		{"response_text", "", `{"id": "1"}`},
+		{"response_json", "id", "1"},
+		{"response_time", "", "1500"},
+		{"response_size", "", "11"},
+	} {
+		v, err := r.source(test.source, test.property)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.source, test.property, err)
+			continue
+		}
+		if actual := runner.Stringify(v); actual != test.expected {
+			t.Errorf("%s %s: expected %q, got %q", test.source, test.property, test.expected, actual)
+		}
+	}
+
+	if _, err := r.source("response_cookies", ""); err == nil {
+		t.Error("expected an error for an unsupported source")
+	}
+	if _, err := (response{body: []byte("text")}).source("response_json", "id"); err == nil {
+		t.Error("expected an error for a response that is not JSON")
+	}
+}
@@ -0,0 +1,78 @@
+// Package runscope runs the requests and assertions of a Runscope API test export, like the Okta SCIM 2.0 Spec Test
+// (Okta-SCIM-20-SPEC-Test.json), against a SCIM server. New versions of a spec test can be run as is, without porting
+// them to a test suite first.
+package runscope
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+// Test is a Runscope API test export.
+type Test struct {
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Steps        []Step        `json:"steps"`
+	Environments []Environment `json:"environments"`
+}
+
+// Step is a step of a test, only request and pause steps are supported.
+type Step struct {
+	StepType   string              `json:"step_type"`
+	Note       string              `json:"note"`
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	Assertions []Assertion         `json:"assertions"`
+	Variables  []Variable          `json:"variables"`
+	// Duration is the number of seconds of a pause step.
+	Duration float64 `json:"duration"`
+	// Scripts and BeforeScripts are JavaScript, they are not executed.
+	Scripts       []string `json:"scripts"`
+	BeforeScripts []string `json:"before_scripts"`
+}
+
+// Assertion compares a property of the response with a value.
+type Assertion struct {
+	Source     string      `json:"source"`
+	Property   string      `json:"property"`
+	Comparison string      `json:"comparison"`
+	Value      runner.Text `json:"value"`
+}
+
+// Variable extracts a property of the response into a variable that can be used by the following steps.
+type Variable struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Property string `json:"property"`
+}
+
+// Environment holds the initial values of the variables.
+type Environment struct {
+	Name             string            `json:"name"`
+	InitialVariables map[string]string `json:"initial_variables"`
+}
+
+// Load reads a Runscope API test export.
+func Load(r io.Reader) (*Test, error) {
+	var test Test
+	if err := json.NewDecoder(r).Decode(&test); err != nil {
+		return nil, fmt.Errorf("invalid runscope test: %v", err)
+	}
+	return &test, nil
+}
+
+// LoadFile reads the Runscope API test export in the given file.
+func LoadFile(name string) (*Test, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
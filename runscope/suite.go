@@ -0,0 +1,167 @@
+package runscope
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// DefaultBaseURLVariable is the variable that holds the base URL of the SCIM server in the Okta spec test.
+const DefaultBaseURLVariable = "SCIMBaseURL"
+
+// Suite runs the steps of a Runscope test as subtests, with a subtest for every assertion of a step (e.g.
+// "Test_Users_endpoint/Assertion_0"). The base URL, middleware and retries of the suite are used for every request.
+type Suite struct {
+	util.Suite
+	test            *Test
+	environment     string
+	variables       map[string]string
+	baseURLVariable string
+}
+
+// NewSuite returns a suite that runs the steps of the given test.
+func NewSuite(test *Test) *Suite {
+	return &Suite{
+		test:            test,
+		variables:       make(map[string]string),
+		baseURLVariable: DefaultBaseURLVariable,
+	}
+}
+
+// SetEnvironment selects the environment of the test by name, that holds the initial values of the variables. The
+// first environment is used by default.
+func (s *Suite) SetEnvironment(name string) {
+	s.environment = name
+}
+
+// SetVariable sets the value of a variable, it overrides the initial value of the environment. Scripts are not
+// executed, so the variables that they would set have to be set this way.
+func (s *Suite) SetVariable(name, value string) {
+	s.variables[name] = value
+}
+
+// SetBaseURLVariable sets the name of the variable that holds the base URL of the SCIM server, it refers to the base
+// URL of the suite.
+func (s *Suite) SetBaseURLVariable(name string) {
+	s.baseURLVariable = name
+}
+
+// TestSteps runs the steps of the test after one another. Steps that refer to variables without a value (e.g.
+// because a previous step failed) are skipped.
+func (s *Suite) TestSteps() {
+	variables := s.initialVariables()
+	for i, step := range s.test.Steps {
+		step := step
+		name := step.Note
+		if name == "" {
+			name = fmt.Sprintf("Step %d", i)
+		}
+		s.Run(name, func() {
+			switch step.StepType {
+			case "request", "":
+				s.request(step, variables)
+			case "pause":
+				time.Sleep(time.Duration(step.Duration * float64(time.Second)))
+			default:
+				s.T().Skipf("unsupported step type: %q", step.StepType)
+			}
+		})
+	}
+}
+
+// initialVariables returns the variables of the environment, overridden by the ones that were set.
+func (s *Suite) initialVariables() map[string]string {
+	variables := make(map[string]string)
+	for i, env := range s.test.Environments {
+		if s.environment == "" && i == 0 || env.Name == s.environment {
+			for k, v := range env.InitialVariables {
+				variables[k], _ = resolve(v, nil)
+			}
+			break
+		}
+	}
+	for k, v := range s.variables {
+		variables[k] = v
+	}
+	variables[s.baseURLVariable] = ""
+	return variables
+}
+
+// request sends the request of the step, checks its assertions and extracts its variables.
+func (s *Suite) request(step Step, variables map[string]string) {
+	if len(step.Scripts) != 0 || len(step.BeforeScripts) != 0 {
+		s.T().Log("scripts are not executed")
+	}
+
+	target, missing := resolve(step.URL, variables)
+	body, missingBody := resolve(step.Body, variables)
+	if missing = append(missing, missingBody...); len(missing) != 0 {
+		s.T().Skipf("variables without a value: %s", strings.Join(missing, ", "))
+	}
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	var req *http.Request
+	if strings.Contains(target, "://") {
+		var err error
+		req, err = http.NewRequest(step.Method, target, reader)
+		s.Require().NoError(err)
+	} else {
+		req = s.NewRequest(step.Method, target, reader)
+	}
+	for key, values := range step.Headers {
+		for _, value := range values {
+			// headers like "Authorization: {{auth}}" are left out without a value, the middleware can set them.
+			if value, missing := resolve(value, variables); len(missing) == 0 {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	start := time.Now()
+	resp := s.Do(req)
+	raw, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	s.Require().NoError(err)
+	r := response{
+		status:  resp.StatusCode,
+		header:  resp.Header,
+		body:    raw,
+		latency: time.Since(start),
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	_ = d.Decode(&r.json)
+
+	for i, assertion := range step.Assertions {
+		assertion := assertion
+		s.Run(fmt.Sprintf("Assertion %d", i), func() {
+			expected, missing := resolve(string(assertion.Value), variables)
+			if len(missing) != 0 {
+				s.T().Skipf("variables without a value: %s", strings.Join(missing, ", "))
+			}
+			actual, err := r.source(assertion.Source, assertion.Property)
+			s.Require().NoError(err)
+			ok, err := compare(assertion.Comparison, actual, expected)
+			s.Require().NoError(err)
+			s.True(ok, "%s %s %s %q, actual: %s",
+				assertion.Source, assertion.Property, assertion.Comparison, expected, runner.Stringify(actual))
+		})
+	}
+
+	for _, variable := range step.Variables {
+		if v, err := r.source(variable.Source, variable.Property); err == nil && v != nil {
+			variables[variable.Name] = runner.Stringify(v)
+		}
+	}
+}
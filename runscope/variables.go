@@ -0,0 +1,44 @@
+package runscope
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/internal/runner"
+)
+
+// resolve replaces the placeholders in the template with the values of the variables and the results of the built-in
+// functions. It returns the names of the placeholders that could not be resolved.
+func resolve(template string, variables map[string]string) (string, []string) {
+	return runner.Resolve(template, variables, builtin)
+}
+
+// builtin returns the result of the Runscope built-in function with the given name.
+func builtin(name string) (string, bool) {
+	function, args := name, ""
+	if i := strings.Index(name, "("); i != -1 && strings.HasSuffix(name, ")") {
+		function, args = name[:i], strings.TrimSpace(name[i+1:len(name)-1])
+	}
+
+	switch function {
+	case "random_string":
+		n := 10
+		if args != "" {
+			var err error
+			if n, err = strconv.Atoi(args); err != nil {
+				return "", false
+			}
+		}
+		return runner.RandomString(n), true
+	case "random_int":
+		return runner.RandomInt(1 << 31), true
+	case "uuid":
+		return runner.UUID(), true
+	case "timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), true
+	case "utc_datetime":
+		return time.Now().UTC().Format(time.RFC3339), true
+	}
+	return "", false
+}
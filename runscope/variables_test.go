@@ -0,0 +1,107 @@
+package runscope
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestResolve(t *testing.T) {
+	variables := map[string]string{
+		"SCIMBaseURL": "https://example.com/v2",
+		"id":          "1",
+		"empty":       "",
+	}
+	for _, test := range []struct {
+		template string
+		expected string
+		missing  []string
+	}{
+		{"{{SCIMBaseURL}}/Users/{{id}}", "https://example.com/v2/Users/1", nil},
+		{"{{ id }}", "1", nil},
+		{"/Users/{{empty}}", "/Users/", nil},
+		{"no placeholders", "no placeholders", nil},
+		// placeholders without a value are left in place.
+		{"/Users/{{userId}}", "/Users/{{userId}}", []string{"userId"}},
+		{"{{a}}/{{id}}/{{b}}", "{{a}}/1/{{b}}", []string{"a", "b"}},
+		{"{{ID}}", "{{ID}}", []string{"ID"}},
+		{"{{unknown_function(1)}}", "{{unknown_function(1)}}", []string{"unknown_function(1)"}},
+	} {
+		resolved, missing := resolve(test.template, variables)
+		if resolved != test.expected || !reflect.DeepEqual(missing, test.missing) {
+			t.Errorf("%q: expected %q %v, got %q %v", test.template, test.expected, test.missing, resolved, missing)
+		}
+	}
+
+	// variables take precedence over built-in functions.
+	if resolved, _ := resolve("{{timestamp}}", map[string]string{"timestamp": "now"}); resolved != "now" {
+		t.Errorf("expected the variable, got %q", resolved)
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		pattern string // empty if the function does not exist
+	}{
+		{"random_string", `^[0-9a-f]{10}$`},
+		{"random_string()", `^[0-9a-f]{10}$`},
+		{"random_string(5)", `^[0-9a-f]{5}$`},
+		{"random_string( 3 )", `^[0-9a-f]{3}$`},
+		{"random_int", `^\d+$`},
+		{"uuid", `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`},
+		{"timestamp", `^\d{10,}$`},
+		{"utc_datetime", `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`},
+		{"random_string(five)", ""},
+		{"random_strings", ""},
+		{"uuid(", ""},
+	} {
+		v, ok := builtin(test.name)
+		if ok != (test.pattern != "") {
+			t.Errorf("%s: expected %v, got %v", test.name, test.pattern != "", ok)
+			continue
+		}
+		if ok && !regexp.MustCompile(test.pattern).MatchString(v) {
+			t.Errorf("%s: %q does not match %s", test.name, v, test.pattern)
+		}
+	}
+
+	a, _ := builtin("random_string")
+	if b, _ := builtin("random_string"); a == b {
+		t.Errorf("expected random strings, got %q twice", a)
+	}
+}
+
+// TestMissingVariables checks that the steps and assertions that refer to variables without a value are skipped,
+// without sending their request.
+func TestMissingVariables(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/scim+json")
+		_, _ = w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer server.Close()
+
+	s := NewSuite(&Test{Steps: []Step{
+		{Note: "Create", Method: http.MethodPost, URL: "{{SCIMBaseURL}}/Users", Variables: []Variable{
+			{Name: "id", Source: "response_json", Property: "id"},
+			{Name: "missing", Source: "response_json", Property: "unknown"},
+		}},
+		{Note: "Get", Method: http.MethodGet, URL: "{{SCIMBaseURL}}/Users/{{id}}", Assertions: []Assertion{
+			{Source: "response_json", Property: "id", Comparison: "equal", Value: "{{id}}"},
+			{Source: "response_json", Property: "id", Comparison: "equal", Value: "{{missing}}"},
+		}},
+		{Note: "Delete", Method: http.MethodDelete, URL: "{{SCIMBaseURL}}/Users/{{missing}}"},
+	}})
+	s.BaseURL(server.URL)
+	suite.Run(t, s)
+
+	if expected := []string{"/Users", "/Users/1"}; !reflect.DeepEqual(expected, paths) {
+		t.Errorf("expected requests to %v, got %v", expected, paths)
+	}
+}
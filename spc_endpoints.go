@@ -2,6 +2,8 @@ package suite
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 )
 
 // RFC: https://tools.ietf.org/html/rfc7644#section-4
@@ -20,7 +22,7 @@ func (suite *SCIMTestSuite) TestServiceProviderConfigurationEndpoints() {
 	})
 
 	suite.Run("ForbiddenFilter", func() {
-		suite.testResourceTypesEndpoint()
+		suite.testForbiddenFilter()
 	})
 }
 
@@ -80,9 +82,13 @@ func (suite *SCIMTestSuite) testResourceTypesEndpoint() {
 func (suite *SCIMTestSuite) testForbiddenFilter() {
 	// If a "filter" is provided, the service provider SHOULD respond with HTTP status code 403 (Forbidden) to ensure
 	// that clients cannot incorrectly assume that any matching conditions specified in a filter are true.
-	respS := suite.Get("/Schemas?startIndex=1&count=10")
-	suite.StatusForbidden(respS.StatusCode)
+	filter := url.Values{
+		"filter": []string{"id pr"},
+	}
+
+	respS := suite.Get(fmt.Sprintf("/Schemas?%s", filter.Encode()))
+	suite.Should().Equal(http.StatusForbidden, respS.StatusCode)
 
-	respRT := suite.Get("/ResourceTypes?startIndex=1&count=10")
-	suite.StatusForbidden(respRT.StatusCode)
+	respRT := suite.Get(fmt.Sprintf("/ResourceTypes?%s", filter.Encode()))
+	suite.Should().Equal(http.StatusForbidden, respRT.StatusCode)
 }
@@ -4,6 +4,25 @@ import (
 	"github.com/di-wu/scim-test-suite/util"
 )
 
+// defaultLargeGroupSize is the number of members of the group in TestLargeGroup.
+const defaultLargeGroupSize = 2000
+
+// defaultRateLimitCeiling is the maximum number of requests that TestRateLimit sends to get rate limited.
+const defaultRateLimitCeiling = 500
+
 type SCIMTestSuite struct {
 	util.Suite
+	largeGroupSize   int
+	rateLimitCeiling int
+}
+
+// LargeGroupSize sets the number of members of the group in TestLargeGroup, it is skipped if the size is negative.
+func (suite *SCIMTestSuite) LargeGroupSize(size int) {
+	suite.largeGroupSize = size
+}
+
+// RateLimitCeiling sets the maximum number of requests that TestRateLimit sends to get rate limited, it is skipped if
+// the ceiling is negative.
+func (suite *SCIMTestSuite) RateLimitCeiling(ceiling int) {
+	suite.rateLimitCeiling = ceiling
 }
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.9
+
+const listResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+
+// partialResponse serves the request and applies its "attributes" and "excludedAttributes" parameters to the
+// resources in the response, the scim package ignores them.
+func (s ReferenceServer) partialResponse(w http.ResponseWriter, r *http.Request) {
+	rec := httptest.NewRecorder()
+	s.serve(rec, r)
+
+	var (
+		query      = r.URL.Query()
+		attributes = splitAttributes(query.Get("attributes"))
+		excluded   = splitAttributes(query.Get("excludedAttributes"))
+	)
+	var body map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	d.UseNumber()
+	if rec.Code < http.StatusBadRequest && d.Decode(&body) == nil {
+		resources := []interface{}{body}
+		if schemas, _ := body["schemas"].([]interface{}); len(schemas) == 1 && schemas[0] == listResponseSchema {
+			resources, _ = body["Resources"].([]interface{})
+		}
+		for _, resource := range resources {
+			if resource, ok := resource.(map[string]interface{}); ok && resource["id"] != nil {
+				project(resource, attributes, excluded)
+			}
+		}
+		if raw, err := json.Marshal(body); err == nil {
+			rec.Body = bytes.NewBuffer(raw)
+		}
+	}
+	copyResponse(w, rec)
+}
+
+// splitAttributes splits the comma separated list of attribute names.
+func splitAttributes(list string) []string {
+	var attributes []string
+	for _, attribute := range strings.Split(list, ",") {
+		if attribute = strings.TrimSpace(attribute); attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes
+}
+
+// project reduces the resource to the given attributes, or removes the excluded attributes if none are given. The
+// "id" and "schemas" are always returned.
+func project(resource map[string]interface{}, attributes, excluded []string) {
+	if len(attributes) != 0 {
+		kept := map[string]interface{}{
+			"id":      resource["id"],
+			"schemas": resource["schemas"],
+		}
+		for _, attribute := range attributes {
+			key, sub := attributePath(resource, attribute)
+			k, v, ok := lookup(resource, key)
+			if !ok {
+				continue
+			}
+			if sub == "" {
+				kept[k] = v
+				continue
+			}
+			keepSubAttribute(kept, k, v, sub)
+		}
+		for k := range resource {
+			delete(resource, k)
+		}
+		for k, v := range kept {
+			resource[k] = v
+		}
+		return
+	}
+
+	for _, attribute := range excluded {
+		key, sub := attributePath(resource, attribute)
+		if strings.EqualFold(key, "id") || strings.EqualFold(key, "schemas") {
+			continue
+		}
+		if sub == "" {
+			remove(resource, key)
+			continue
+		}
+		_, v, _ := lookup(resource, key)
+		for _, value := range values(v) {
+			if value, ok := value.(map[string]interface{}); ok {
+				remove(value, sub)
+			}
+		}
+	}
+}
+
+// attributePath splits the (possibly URN qualified) attribute name into the key of the resource and the name of the
+// sub-attribute, if any.
+func attributePath(resource map[string]interface{}, attribute string) (string, string) {
+	// value filters are not part of attribute names, but some clients (e.g. Azure AD) use them anyway.
+	if i, j := strings.Index(attribute, "["), strings.LastIndex(attribute, "]"); i != -1 && i < j {
+		attribute = attribute[:i] + attribute[j+1:]
+	}
+	if strings.HasPrefix(strings.ToLower(attribute), "urn:") {
+		for k := range resource {
+			if strings.EqualFold(attribute, k) {
+				// an extension as a whole.
+				return k, ""
+			}
+			if strings.HasPrefix(strings.ToLower(attribute), strings.ToLower(k)+":") {
+				// attribute of an extension, e.g. "urn:...:enterprise:2.0:User:department".
+				return k, attribute[len(k)+1:]
+			}
+		}
+		attribute = attribute[strings.LastIndex(attribute, ":")+1:]
+	}
+	parts := strings.SplitN(attribute, ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// lookup returns the key and value of the given key (case insensitive).
+func lookup(m map[string]interface{}, key string) (string, interface{}, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return k, v, true
+		}
+	}
+	return "", nil, false
+}
+
+// keepSubAttribute copies the given sub-attribute of the (multi-valued) complex value to the kept attributes.
+func keepSubAttribute(kept map[string]interface{}, key string, value interface{}, sub string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		object, _ := kept[key].(map[string]interface{})
+		if object == nil {
+			object = make(map[string]interface{})
+		}
+		if k, v, ok := lookup(value, sub); ok {
+			object[k] = v
+		}
+		kept[key] = object
+	case []interface{}:
+		list, _ := kept[key].([]interface{})
+		if list == nil {
+			list = make([]interface{}, len(value))
+			for i := range list {
+				list[i] = make(map[string]interface{})
+			}
+		}
+		for i, element := range value {
+			element, _ := element.(map[string]interface{})
+			if k, v, ok := lookup(element, sub); ok {
+				list[i].(map[string]interface{})[k] = v
+			}
+		}
+		kept[key] = list
+	}
+}
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// projectUser is the user that gets projected in TestProject.
+const projectUser = `{
+	"id": "0001",
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],
+	"userName": "bjensen",
+	"name": {"givenName": "Barbara", "familyName": "Jensen"},
+	"emails": [
+		{"value": "bjensen@example.com", "type": "work"},
+		{"value": "babs@jensen.org", "type": "home"}
+	],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Sales", "employeeNumber": "1"}
+}`
+
+func TestAttributePath(t *testing.T) {
+	resource := decode(t, projectUser)
+	for _, test := range []struct {
+		attribute string
+		key, sub  string
+	}{
+		{"userName", "userName", ""},
+		{"name.givenName", "name", "givenName"},
+		{`emails[type eq "work"].value`, "emails", "value"},
+		{"urn:ietf:params:scim:schemas:core:2.0:User:userName", "userName", ""},
+		{"urn:ietf:params:scim:schemas:core:2.0:User:name.familyName", "name", "familyName"},
+		{enterpriseUser, enterpriseUser, ""},
+		{"URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER", enterpriseUser, ""},
+		{enterpriseUser + ":department", enterpriseUser, "department"},
+	} {
+		if key, sub := attributePath(resource, test.attribute); key != test.key || sub != test.sub {
+			t.Errorf("%s: expected %q %q, got %q %q", test.attribute, test.key, test.sub, key, sub)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	for _, test := range []struct {
+		name                 string
+		attributes, excluded []string
+		// expected holds the attributes next to the id and schemas.
+		expected string
+	}{
+		{"attribute", []string{"userName"}, nil, `{"userName": "bjensen"}`},
+		{"case insensitive", []string{"USERNAME"}, nil, `{"userName": "bjensen"}`},
+		{"unknown attribute", []string{"nickName"}, nil, `{}`},
+		{"sub attribute", []string{"name.givenName"}, nil, `{"name": {"givenName": "Barbara"}}`},
+		{"sub attributes", []string{"name.givenName", "name.familyName"}, nil, `{"name": {"givenName": "Barbara", "familyName": "Jensen"}}`},
+		{"multi valued sub attribute", []string{"emails.type"}, nil, `{"emails": [{"type": "work"}, {"type": "home"}]}`},
+		{"extension", []string{enterpriseUser}, nil, `{"` + enterpriseUser + `": {"department": "Sales", "employeeNumber": "1"}}`},
+		{"extension attribute", []string{enterpriseUser + ":department"}, nil, `{"` + enterpriseUser + `": {"department": "Sales"}}`},
+		{"id and schemas", []string{"id"}, nil, `{}`},
+		// excluded attributes are ignored if attributes are given.
+		{"attributes and excluded", []string{"userName"}, []string{"userName"}, `{"userName": "bjensen"}`},
+		{"excluded", nil, []string{"name", "emails"}, `{
+			"userName": "bjensen",
+			"` + enterpriseUser + `": {"department": "Sales", "employeeNumber": "1"}
+		}`},
+		{"excluded sub attribute", nil, []string{"name.givenName", "emails.type", "id", "schemas"}, `{
+			"userName": "bjensen",
+			"name": {"familyName": "Jensen"},
+			"emails": [{"value": "bjensen@example.com"}, {"value": "babs@jensen.org"}],
+			"` + enterpriseUser + `": {"department": "Sales", "employeeNumber": "1"}
+		}`},
+		{"excluded extension", nil, []string{enterpriseUser}, `{
+			"userName": "bjensen",
+			"name": {"givenName": "Barbara", "familyName": "Jensen"},
+			"emails": [{"value": "bjensen@example.com", "type": "work"}, {"value": "babs@jensen.org", "type": "home"}]
+		}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resource := decode(t, projectUser)
+			project(resource, test.attributes, test.excluded)
+
+			expected := decode(t, test.expected)
+			user := decode(t, projectUser)
+			expected["id"], expected["schemas"] = user["id"], user["schemas"]
+			if !reflect.DeepEqual(expected, resource) {
+				t.Errorf("expected %v, got %v", expected, resource)
+			}
+		})
+	}
+}
+
+func TestKeepSubAttribute(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		kept     string
+		value    interface{}
+		sub      string
+		expected string
+	}{
+		{"complex", `{}`, map[string]interface{}{"givenName": "Barbara", "familyName": "Jensen"}, "GIVENNAME", `{"attr": {"givenName": "Barbara"}}`},
+		{"merged", `{"attr": {"familyName": "Jensen"}}`, map[string]interface{}{"givenName": "Barbara"}, "givenName", `{"attr": {"givenName": "Barbara", "familyName": "Jensen"}}`},
+		{"missing", `{}`, map[string]interface{}{"givenName": "Barbara"}, "familyName", `{"attr": {}}`},
+		{"multi valued", `{}`, []interface{}{
+			map[string]interface{}{"value": "a", "type": "work"},
+			map[string]interface{}{"type": "home"},
+		}, "value", `{"attr": [{"value": "a"}, {}]}`},
+		{"multi valued merged", `{"attr": [{"type": "work"}, {"type": "home"}]}`, []interface{}{
+			map[string]interface{}{"value": "a", "type": "work"},
+			map[string]interface{}{"value": "b", "type": "home"},
+		}, "value", `{"attr": [{"value": "a", "type": "work"}, {"value": "b", "type": "home"}]}`},
+		{"simple", `{}`, "bjensen", "value", `{}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			kept := decode(t, test.kept)
+			keepSubAttribute(kept, "attr", test.value, test.sub)
+			if expected := decode(t, test.expected); !reflect.DeepEqual(expected, kept) {
+				t.Errorf("expected %v, got %v", expected, kept)
+			}
+		})
+	}
+}
+
+func TestPartialResponse(t *testing.T) {
+	s := Server()
+	for _, test := range []struct {
+		name  string
+		path  string
+		query url.Values
+		// expected are the attributes of every returned resource.
+		expected []string
+	}{
+		{"resource", "/Users/0001", url.Values{"attributes": {"userName"}}, []string{"id", "schemas", "userName"}},
+		{"list", "/Users", url.Values{"attributes": {"userName,name.givenName"}}, []string{"id", "schemas", "userName", "name"}},
+		{"excluded", "/Groups/0021", url.Values{"excludedAttributes": {"meta,members"}}, []string{"id", "schemas", "externalId", "displayName"}},
+		{"unknown", "/Users/0001", url.Values{"attributes": {"unknown"}}, []string{"id", "schemas"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.partialResponse(rec, httptest.NewRequest(http.MethodGet, test.path+"?"+test.query.Encode(), nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status code: %d", rec.Code)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			resources := []interface{}{body}
+			if list, ok := body["Resources"].([]interface{}); ok {
+				if body["totalResults"] == nil || len(list) == 0 {
+					t.Fatalf("expected the list response to be left intact, got %v", body)
+				}
+				resources = list
+			}
+			for _, resource := range resources {
+				var keys []string
+				for k := range resource.(map[string]interface{}) {
+					keys = append(keys, k)
+				}
+				if !sameElements(keys, test.expected) {
+					t.Errorf("expected %v, got %v", test.expected, keys)
+				}
+			}
+		})
+	}
+
+	// errors are left untouched.
+	rec := httptest.NewRecorder()
+	s.partialResponse(rec, httptest.NewRequest(http.MethodGet, "/Users/unknown?attributes=userName", nil))
+	var scimError map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &scimError); rec.Code != http.StatusNotFound || err != nil || scimError["detail"] == nil {
+		t.Errorf("expected the error to be left intact, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[string]int)
+	for _, e := range a {
+		count[e]++
+	}
+	for _, e := range b {
+		count[e]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
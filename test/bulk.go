@@ -0,0 +1,254 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.7
+
+const (
+	bulkRequestSchema  = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	bulkResponseSchema = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+
+	maxOperations  = 1000
+	maxPayloadSize = 1048576
+
+	bulkIDPrefix = "bulkId:"
+)
+
+type bulkOperation struct {
+	Method  string
+	BulkID  string
+	Version string
+	Path    string
+	Data    interface{}
+}
+
+type bulkResult struct {
+	Method   string      `json:"method"`
+	BulkID   string      `json:"bulkId,omitempty"`
+	Version  string      `json:"version,omitempty"`
+	Location string      `json:"location,omitempty"`
+	Status   string      `json:"status"`
+	Response interface{} `json:"response,omitempty"`
+}
+
+// bulk handles POST requests to the "/Bulk" endpoint. Operations are executed in order, operations that reference
+// the bulkId of an operation that has not been executed yet are postponed until it is.
+func (s ReferenceServer) bulk(w http.ResponseWriter, r *http.Request) {
+	raw, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		writeError(w, errors.ScimErrorInvalidSyntax)
+		return
+	}
+	if len(raw) > maxPayloadSize {
+		writeError(w, errors.ScimError{
+			Detail: fmt.Sprintf("The size of the bulk operation exceeds the maxPayloadSize (%d).", maxPayloadSize),
+			Status: http.StatusRequestEntityTooLarge,
+		})
+		return
+	}
+
+	var req struct {
+		Schemas      []string
+		FailOnErrors int
+		Operations   []bulkOperation
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&req); err != nil || !contains(req.Schemas, bulkRequestSchema) {
+		writeError(w, errors.ScimErrorInvalidSyntax)
+		return
+	}
+	if len(req.Operations) > maxOperations {
+		writeError(w, errors.ScimError{
+			Detail: fmt.Sprintf("The number of operations exceeds the maxOperations (%d).", maxOperations),
+			Status: http.StatusRequestEntityTooLarge,
+		})
+		return
+	}
+
+	var (
+		results  = make([]*bulkResult, len(req.Operations))
+		ids      = make(map[string]string)
+		failures int
+	)
+	for progress := true; progress && (req.FailOnErrors == 0 || failures < req.FailOnErrors); {
+		progress = false
+		for i, op := range req.Operations {
+			if results[i] != nil {
+				continue
+			}
+			path, ok := resolveBulkIDs(op.Path, ids).(string)
+			data := resolveBulkIDs(op.Data, ids)
+			if !ok || unresolved(path) || unresolved(data) {
+				continue
+			}
+
+			result := s.bulkOperation(r, op, path, data)
+			results[i], progress = &result, true
+			if result.Location == "" {
+				failures++
+				if req.FailOnErrors != 0 && failures >= req.FailOnErrors {
+					break
+				}
+				continue
+			}
+			if op.BulkID != "" {
+				ids[op.BulkID] = result.Location[strings.LastIndex(result.Location, "/")+1:]
+			}
+		}
+	}
+
+	operations := make([]*bulkResult, 0)
+	for i, op := range req.Operations {
+		result := results[i]
+		if result == nil {
+			if req.FailOnErrors != 0 && failures >= req.FailOnErrors {
+				// operations after reaching the error limit are not executed.
+				continue
+			}
+			// the referenced bulkId does not exist, or the references are circular.
+			result = &bulkResult{
+				Method: op.Method,
+				BulkID: op.BulkID,
+				Status: strconv.Itoa(http.StatusConflict),
+				Response: errors.ScimError{
+					ScimType: errors.ScimTypeInvalidValue,
+					Detail:   "The referenced bulkId could not be resolved.",
+					Status:   http.StatusConflict,
+				},
+			}
+		}
+		operations = append(operations, result)
+	}
+
+	write(w, http.StatusOK, map[string]interface{}{
+		"schemas":    []string{bulkResponseSchema},
+		"Operations": operations,
+	})
+}
+
+// bulkOperation executes a single operation of a bulk request, as if it was sent on its own. The location of the
+// result is only set if the operation succeeded.
+func (s ReferenceServer) bulkOperation(r *http.Request, op bulkOperation, path string, data interface{}) bulkResult {
+	result := bulkResult{
+		Method: op.Method,
+		BulkID: op.BulkID,
+	}
+
+	method := strings.ToUpper(op.Method)
+	switch {
+	case method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete,
+		method == http.MethodPost && op.BulkID == "",
+		!strings.HasPrefix(path, "/"):
+		result.Status = strconv.Itoa(http.StatusBadRequest)
+		result.Response = errors.ScimErrorInvalidSyntax
+		return result
+	}
+
+	var body io.Reader = http.NoBody
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			result.Status = strconv.Itoa(http.StatusBadRequest)
+			result.Response = errors.ScimErrorInvalidSyntax
+			return result
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	base := strings.TrimSuffix(r.URL.Path, "/Bulk")
+	req, err := http.NewRequest(method, base+path, body)
+	if err != nil {
+		result.Status = strconv.Itoa(http.StatusBadRequest)
+		result.Response = errors.ScimErrorInvalidSyntax
+		return result
+	}
+	req = req.WithContext(r.Context())
+	req.Host, req.TLS = r.Host, r.TLS
+	req.Header = r.Header.Clone()
+	req.Header.Del("If-Match")
+	if op.Version != "" {
+		req.Header.Set("If-Match", op.Version)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handle(rec, req)
+
+	result.Status = strconv.Itoa(rec.Code)
+	result.Version = rec.Header().Get("Etag")
+	if rec.Code >= http.StatusBadRequest {
+		var response interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		result.Response = response
+		return result
+	}
+
+	if method == http.MethodPost {
+		result.Location = rec.Header().Get("Location")
+	} else {
+		result.Location = absoluteURL(r, base+path)
+	}
+	return result
+}
+
+// resolveBulkIDs replaces all "bulkId:" references in the given value with the ids of the created resources.
+func resolveBulkIDs(v interface{}, ids map[string]string) interface{} {
+	switch v := v.(type) {
+	case string:
+		// references are either the whole value or a segment of a path.
+		segments := strings.Split(v, "/")
+		for i, segment := range segments {
+			if id, ok := ids[strings.TrimPrefix(segment, bulkIDPrefix)]; ok && strings.HasPrefix(segment, bulkIDPrefix) {
+				segments[i] = id
+			}
+		}
+		return strings.Join(segments, "/")
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = resolveBulkIDs(e, ids)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = resolveBulkIDs(e, ids)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// unresolved returns whether the value still contains "bulkId:" references.
+func unresolved(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.Contains(v, bulkIDPrefix)
+	case map[string]interface{}:
+		for _, e := range v {
+			if unresolved(e) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if unresolved(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package test_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type cleanupSuite struct {
+	util.Suite
+	creates int
+}
+
+func (s *cleanupSuite) TestCreate() {
+	for i := 0; i < s.creates; i++ {
+		resp := s.Post("/Users", strings.NewReader(`{}`))
+		s.Equal(http.StatusCreated, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+}
+
+// TestCleanup checks that the resources that were created get removed on teardown, whatever the form of their
+// Location header.
+func TestCleanup(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created int
+		deleted []string
+		server  *httptest.Server
+	)
+	locations := []func(id int) string{
+		func(id int) string { return fmt.Sprintf("%s/v2/Users/%d", server.URL, id) },
+		// path-only and relative locations are resolved against the base URL.
+		func(id int) string { return fmt.Sprintf("/v2/Users/%d", id) },
+		func(id int) string { return fmt.Sprintf("Users/%d", id) },
+		// locations of other hosts fall back on the id in the body.
+		func(id int) string { return fmt.Sprintf("https://example.com/v2/Users/%d", id) },
+		func(id int) string { return "" },
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPost:
+			id := 100 + created
+			if location := locations[created](id); location != "" {
+				w.Header().Set("Location", location)
+			}
+			created++
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"id": "%d"}`, id)
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	s := &cleanupSuite{creates: len(locations)}
+	s.BaseURL(server.URL + "/v2/")
+	suite.Run(t, s)
+
+	sort.Strings(deleted)
+	expected := []string{"/v2/Users/100", "/v2/Users/101", "/v2/Users/102", "/v2/Users/103", "/v2/Users/104"}
+	if !reflect.DeepEqual(expected, deleted) {
+		t.Errorf("expected %v to be removed, got %v", expected, deleted)
+	}
+}
@@ -0,0 +1,24 @@
+package test_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	scim "github.com/di-wu/scim-test-suite"
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+// TestConcurrency runs the concurrency tests against the reference server with ETags enabled, so none of them are
+// skipped.
+func TestConcurrency(t *testing.T) {
+	s := test.Server()
+	s.Features.ETag = true
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	suite := new(scim.SCIMTestSuite)
+	suite.SetT(t)
+	suite.BaseURL(server.URL)
+	suite.Strict(true)
+	suite.TestConcurrency()
+}
@@ -0,0 +1,321 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// Fault is a spec violation that can be injected into the reference server, to check whether the test suites catch
+// it. A fault is caught if at least one of the tests it is paired with fails.
+type Fault struct {
+	Name        string
+	Description string
+	// Catches are the tests that are expected to catch the fault, prefixed with the name of their suite: "scim",
+	// "okta", "okta-groups" or "azure" (e.g. "okta/TestCreateUser/StatusCode"). Subtests of the given tests are
+	// included.
+	Catches []string
+
+	// handler wraps the handler of the server to inject the fault.
+	handler func(next http.Handler) http.Handler
+	// handlers injects the fault into the resource handlers of the server.
+	handlers func(h *ResourceHandler)
+}
+
+// Faulty returns the reference server with the given faults injected. The faults are injected into the resource
+// handlers of the given server as well, so it should not be used on its own anymore.
+func Faulty(s ReferenceServer, faults ...Fault) http.Handler {
+	var handler http.Handler = s
+	for _, fault := range faults {
+		if fault.handlers != nil {
+			for _, resourceType := range s.ResourceTypes {
+				if h, ok := resourceType.Handler.(*ResourceHandler); ok {
+					fault.handlers(h)
+				}
+			}
+		}
+		if fault.handler != nil {
+			handler = fault.handler(handler)
+		}
+	}
+	return handler
+}
+
+// Faults returns all the faults that can be injected, with their default configuration.
+func Faults() []Fault {
+	return []Fault{
+		WrongStatusCode(),
+		MissingSchemas(),
+		StringTotalResults(),
+		CaseSensitiveUniqueness(),
+		MissingLocation(),
+		CaseSensitivePatchOperations(),
+		StrictBooleans(),
+		MissingEnterpriseExtension(),
+		Latency(time.Second),
+		InternalServerErrors(http.MethodGet, "/ResourceTypes",
+			"scim/TestServiceProviderConfigurationEndpoints/ResourceTypes",
+			"azure/TestEndpoints/Get_ResourceTypes/Status_code_is_200",
+		),
+	}
+}
+
+// WrongStatusCode responds with 200 (OK) instead of 201 (Created) when a resource is created.
+func WrongStatusCode() Fault {
+	return Fault{
+		Name:        "wrong-status-code",
+		Description: "Responds with 200 instead of 201 when a resource is created.",
+		Catches: []string{
+			"scim/TestCreateUser/Created",
+			"okta/TestCreateUser/StatusCode",
+			"okta-groups/TestGroupPush/CreateGroup/StatusCode",
+			"azure/TestUsers/Post_User/Status_code_is_201",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rewrite(next, func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if rec.Code == http.StatusCreated {
+					rec.Code = http.StatusOK
+				}
+			})
+		},
+	}
+}
+
+// MissingSchemas leaves out the "schemas" attribute of all responses and the resources they contain.
+func MissingSchemas() Fault {
+	return Fault{
+		Name:        "missing-schemas",
+		Description: "Leaves out the \"schemas\" attribute of all responses.",
+		Catches: []string{
+			"scim/TestServiceProviderConfigurationEndpoints/ServiceProviderConfig",
+			"okta/TestGetFirstUser/ContainsSchema",
+			"okta/TestCreateUser/ContainsSchema",
+			"okta-groups/TestGroupPush/CreateGroup/ContainsSchema",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rewriteJSON(next, func(body map[string]interface{}) {
+				delete(body, "schemas")
+				resources, _ := body["Resources"].([]interface{})
+				for _, resource := range resources {
+					if resource, ok := resource.(map[string]interface{}); ok {
+						delete(resource, "schemas")
+					}
+				}
+			})
+		},
+	}
+}
+
+// StringTotalResults returns the "totalResults" of list responses as a string instead of a number.
+func StringTotalResults() Fault {
+	return Fault{
+		Name:        "string-total-results",
+		Description: "Returns \"totalResults\" of list responses as a string.",
+		Catches: []string{
+			"scim/TestServiceProviderConfigurationEndpoints/Schemas",
+			"okta/TestGetFirstUser/TotalResultsIsNumber",
+			"okta/TestGetUserByRandomUserName/TotalResultsIsNumber0",
+			"okta-groups/TestGroupPush/FindByDisplayName/TotalResults",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rewriteJSON(next, func(body map[string]interface{}) {
+				if totalResults, ok := body["totalResults"]; ok {
+					body["totalResults"] = fmt.Sprint(totalResults)
+				}
+			})
+		},
+	}
+}
+
+// CaseSensitiveUniqueness allows users with user names that only differ in case, while user names are case
+// insensitive.
+func CaseSensitiveUniqueness() Fault {
+	return Fault{
+		Name:        "case-sensitive-uniqueness",
+		Description: "Allows user names that only differ in case.",
+		Catches: []string{
+			"scim/TestCreateUser/UserNameUniqueness",
+		},
+		handlers: func(h *ResourceHandler) {
+			h.caseExactUserName = true
+		},
+	}
+}
+
+// MissingLocation leaves out the Location header when a resource is created.
+func MissingLocation() Fault {
+	return Fault{
+		Name:        "missing-location",
+		Description: "Leaves out the Location header when a resource is created.",
+		Catches: []string{
+			"scim/TestCreateUser/Location",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rewrite(next, func(r *http.Request, rec *httptest.ResponseRecorder) {
+				rec.Header().Del("Location")
+			})
+		},
+	}
+}
+
+// Latency delays the responses to GET requests on resource type endpoints (i.e. list requests) with the given
+// duration.
+func Latency(d time.Duration) Fault {
+	return Fault{
+		Name:        "latency",
+		Description: fmt.Sprintf("Delays list responses with %s.", d),
+		Catches: []string{
+			"okta/TestGetGroups/ResponseTime",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path := strings.TrimPrefix(r.URL.Path, "/v2")
+				if r.Method == http.MethodGet && (path == "/Users" || path == "/Groups") {
+					time.Sleep(d)
+				}
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+// InternalServerErrors responds with 500 (Internal Server Error) to the requests with the given method and path (e.g.
+// "/ResourceTypes"), the given tests send such a request and are expected to catch the fault.
+func InternalServerErrors(method, path string, catches ...string) Fault {
+	return Fault{
+		Name:        "internal-server-errors",
+		Description: fmt.Sprintf("Responds with 500 to %s %s.", method, path),
+		Catches:     catches,
+		handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == method && strings.TrimPrefix(r.URL.Path, "/v2") == path {
+					writeError(w, errors.ScimErrorInternal)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+// CaseSensitivePatchOperations rejects PATCH operations that are not lowercase (e.g. "Replace"), while operation
+// values are case insensitive.
+func CaseSensitivePatchOperations() Fault {
+	return Fault{
+		Name:        "case-sensitive-patch-operations",
+		Description: "Rejects PATCH operations that are not lowercase.",
+		Catches: []string{
+			"azure/TestPatchCapitalisedOperations",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rejectPatch(next, func(op map[string]interface{}) bool {
+				name, _ := op["op"].(string)
+				return name != strings.ToLower(name)
+			})
+		},
+	}
+}
+
+// StrictBooleans rejects PATCH operations with boolean values as strings (e.g. "False"), like Azure AD sends them.
+func StrictBooleans() Fault {
+	return Fault{
+		Name:        "strict-booleans",
+		Description: "Rejects PATCH operations with boolean values as strings.",
+		Catches: []string{
+			"azure/TestPatchBooleanStrings",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rejectPatch(next, func(op map[string]interface{}) bool {
+				value, _ := op["value"].(string)
+				return strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+			})
+		},
+	}
+}
+
+// MissingEnterpriseExtension leaves out the enterprise extension of users in all responses, while it is stored.
+func MissingEnterpriseExtension() Fault {
+	const uri = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	return Fault{
+		Name:        "missing-enterprise-extension",
+		Description: "Leaves out the enterprise extension of users in all responses.",
+		Catches: []string{
+			"azure/TestEnterpriseUserRoundTrip",
+		},
+		handler: func(next http.Handler) http.Handler {
+			return rewriteJSON(next, func(body map[string]interface{}) {
+				delete(body, uri)
+				resources, _ := body["Resources"].([]interface{})
+				for _, resource := range resources {
+					if resource, ok := resource.(map[string]interface{}); ok {
+						delete(resource, uri)
+					}
+				}
+			})
+		},
+	}
+}
+
+// rejectPatch responds with 400 (invalidValue) to PATCH requests of which one of the operations gets rejected.
+func rejectPatch(next http.Handler, reject func(op map[string]interface{}) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			next.ServeHTTP(w, r)
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, errors.ScimErrorInvalidSyntax)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
+		var body struct {
+			Operations []map[string]interface{}
+		}
+		_ = json.Unmarshal(raw, &body)
+		for _, op := range body.Operations {
+			if reject(op) {
+				writeError(w, errors.ScimErrorInvalidValue)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rewrite lets the given function modify the recorded response of the next handler before it gets written.
+func rewrite(next http.Handler, modify func(r *http.Request, rec *httptest.ResponseRecorder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		modify(r, rec)
+		copyResponse(w, rec)
+	})
+}
+
+// rewriteJSON lets the given function modify the body of JSON responses.
+func rewriteJSON(next http.Handler, modify func(body map[string]interface{})) http.Handler {
+	return rewrite(next, func(r *http.Request, rec *httptest.ResponseRecorder) {
+		var body map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+		d.UseNumber()
+		if err := d.Decode(&body); err != nil {
+			return
+		}
+		modify(body)
+
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return
+		}
+		rec.Body = bytes.NewBuffer(raw)
+	})
+}
@@ -0,0 +1,143 @@
+package test_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	scim "github.com/di-wu/scim-test-suite"
+	azure "github.com/di-wu/scim-test-suite/idp/azure_ad"
+	"github.com/di-wu/scim-test-suite/idp/okta"
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	// faultEnv is the environment variable that holds the name of the fault to inject in TestFaultySuites.
+	faultEnv = "SCIM_TEST_FAULT"
+	// noFault runs TestFaultySuites without injecting any fault.
+	noFault = "none"
+)
+
+var (
+	failed = regexp.MustCompile(`--- FAIL: TestFaultySuites/(\S+)`)
+	// finished matches the result of TestFaultySuites itself, it is missing if the process panicked or got killed.
+	finished = regexp.MustCompile(`--- (PASS|FAIL): TestFaultySuites \(`)
+)
+
+// TestFaults is a mutation check of the test suites: every fault is injected on its own, in a separate process, and
+// at least one of the tests that are paired with it has to fail. Tests that also fail without any fault are ignored.
+func TestFaults(t *testing.T) {
+	if testing.Short() {
+		t.Skip("mutation check runs all suites for every fault")
+	}
+
+	failures, err := runFaultySuites(noFault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseline := make(map[string]bool)
+	for _, failure := range failures {
+		baseline[failure] = true
+	}
+
+	for _, fault := range test.Faults() {
+		fault := fault
+		t.Run(fault.Name, func(t *testing.T) {
+			all, err := runFaultySuites(fault.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var failures []string
+			for _, failure := range all {
+				if !baseline[failure] {
+					failures = append(failures, failure)
+				}
+			}
+			if !caught(fault, failures) {
+				t.Errorf("fault %q (%s) was not caught by: %s", fault.Name, fault.Description, strings.Join(fault.Catches, ", "))
+			}
+		})
+	}
+}
+
+// runFaultySuites runs TestFaultySuites in a separate process and returns the names of the failed tests.
+func runFaultySuites(fault string) ([]string, error) {
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestFaultySuites$", "-test.v")
+	cmd.Env = append(os.Environ(), faultEnv+"="+fault)
+	out, _ := cmd.CombinedOutput()
+	if !finished.Match(out) {
+		return nil, fmt.Errorf("suites did not finish (fault %q):\n%s", fault, out)
+	}
+
+	var failures []string
+	for _, match := range failed.FindAllStringSubmatch(string(out), -1) {
+		failures = append(failures, match[1])
+	}
+	return failures, nil
+}
+
+// caught returns whether one of the failed tests is paired with the fault.
+func caught(fault test.Fault, failures []string) bool {
+	for _, failure := range failures {
+		for _, name := range fault.Catches {
+			if failure == name || strings.HasPrefix(failure, name+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestFaultySuites runs all suites against the reference server with the fault of the environment injected. It only
+// runs as part of TestFaults.
+func TestFaultySuites(t *testing.T) {
+	name := os.Getenv(faultEnv)
+	if name == "" {
+		t.Skip("only runs as part of TestFaults")
+	}
+
+	var faults []test.Fault
+	for _, fault := range test.Faults() {
+		if fault.Name == name {
+			faults = append(faults, fault)
+		}
+	}
+	if len(faults) == 0 && name != noFault {
+		t.Fatalf("unknown fault: %q", name)
+	}
+
+	server := httptest.NewServer(test.Faulty(test.Server(), faults...))
+	defer server.Close()
+
+	t.Run("scim", func(t *testing.T) {
+		s := new(scim.SCIMTestSuite)
+		s.BaseURL(server.URL)
+		s.Strict(true)
+		// the reference server is not rate limited here, see TestRateLimit.
+		s.RateLimitCeiling(-1)
+		suite.Run(t, s)
+	})
+	t.Run("okta", func(t *testing.T) {
+		s := new(okta.TestSuite)
+		s.BaseURL(server.URL)
+		s.Strict(true)
+		suite.Run(t, s)
+	})
+	t.Run("okta-groups", func(t *testing.T) {
+		s := new(okta.GroupPushSuite)
+		s.BaseURL(server.URL)
+		s.Strict(true)
+		suite.Run(t, s)
+	})
+	t.Run("azure", func(t *testing.T) {
+		s := new(azure.TestSuite)
+		s.BaseURL(server.URL)
+		s.Strict(true)
+		suite.Run(t, s)
+	})
+}
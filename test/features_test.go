@@ -0,0 +1,315 @@
+package test_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+func featureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := test.Server()
+	s.Features.Bulk = true
+	s.Features.Search = true
+	s.Features.Sort = true
+	return httptest.NewServer(s)
+}
+
+// bulkOperations returns the operations of the bulk response, in order.
+func bulkOperations(response map[string]interface{}) []map[string]interface{} {
+	var operations []map[string]interface{}
+	list, _ := response["Operations"].([]interface{})
+	for _, op := range list {
+		operations = append(operations, op.(map[string]interface{}))
+	}
+	return operations
+}
+
+// TestBulk checks that bulkIds get resolved, also if they are referenced before the operation that defines them.
+func TestBulk(t *testing.T) {
+	server := featureServer(t)
+	defer server.Close()
+
+	response := do(t, http.MethodPost, server.URL+"/Bulk", http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [
+			{
+				"method": "POST",
+				"path": "/Groups",
+				"bulkId": "group",
+				"data": {
+					"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+					"displayName": "bulk",
+					"members": [{"value": "bulkId:user", "type": "User"}]
+				}
+			},
+			{
+				"method": "POST",
+				"path": "/Users",
+				"bulkId": "user",
+				"data": {
+					"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+					"userName": "bulk"
+				}
+			},
+			{
+				"method": "PATCH",
+				"path": "/Users/bulkId:user",
+				"data": {
+					"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+					"Operations": [{"op": "add", "path": "displayName", "value": "Bulk"}]
+				}
+			},
+			{
+				"method": "DELETE",
+				"path": "/Users/bulkId:unknown"
+			},
+			{
+				"method": "POST",
+				"path": "/Users",
+				"data": {"userName": "without bulkId"}
+			}
+		]
+	}`)
+
+	operations := bulkOperations(response)
+	if len(operations) != 5 {
+		t.Fatalf("expected 5 operations, got %v", response["Operations"])
+	}
+	for i, status := range []string{"201", "201", "200", "409", "400"} {
+		if operations[i]["status"] != status {
+			t.Errorf("operation %d: expected status %s, got %v", i, status, operations[i])
+		}
+	}
+
+	userLocation, _ := operations[1]["location"].(string)
+	groupLocation, _ := operations[0]["location"].(string)
+	if !strings.HasPrefix(userLocation, server.URL+"/Users/") || !strings.HasPrefix(groupLocation, server.URL+"/Groups/") {
+		t.Fatalf("unexpected locations: %q, %q", userLocation, groupLocation)
+	}
+	userID := userLocation[strings.LastIndex(userLocation, "/")+1:]
+
+	group := do(t, http.MethodGet, groupLocation, http.StatusOK, "")
+	members, _ := group["members"].([]interface{})
+	if len(members) != 1 || members[0].(map[string]interface{})["value"] != userID {
+		t.Errorf("expected the bulkId of the member to be resolved to %q, got %v", userID, group["members"])
+	}
+	if user := do(t, http.MethodGet, userLocation, http.StatusOK, ""); user["displayName"] != "Bulk" {
+		t.Errorf("expected the user to be patched, got %v", user)
+	}
+}
+
+// TestBulkFailOnErrors checks that the operations after the error limit are not executed.
+func TestBulkFailOnErrors(t *testing.T) {
+	server := featureServer(t)
+	defer server.Close()
+
+	for _, test := range []struct {
+		failOnErrors int
+		statuses     []string
+	}{
+		{0, []string{"400", "400", "201"}},
+		{1, []string{"400"}},
+		{2, []string{"400", "400"}},
+		{3, []string{"400", "400", "201"}},
+	} {
+		response := do(t, http.MethodPost, server.URL+"/Bulk", http.StatusOK, fmt.Sprintf(`{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"failOnErrors": %d,
+			"Operations": [
+				{"method": "POST", "path": "/Users", "data": {"userName": "without bulkId"}},
+				{"method": "GET", "path": "/Users"},
+				{"method": "POST", "path": "/Users", "bulkId": "user", "data": {"userName": "failOnErrors%d"}}
+			]
+		}`, test.failOnErrors, test.failOnErrors))
+
+		operations := bulkOperations(response)
+		if len(operations) != len(test.statuses) {
+			t.Errorf("failOnErrors %d: expected %d operations, got %v", test.failOnErrors, len(test.statuses), operations)
+			continue
+		}
+		for i, status := range test.statuses {
+			if operations[i]["status"] != status {
+				t.Errorf("failOnErrors %d, operation %d: expected status %s, got %v", test.failOnErrors, i, status, operations[i])
+			}
+		}
+	}
+}
+
+// TestBulkLimits checks that requests beyond the maxOperations and maxPayloadSize of the service provider
+// configuration get rejected.
+func TestBulkLimits(t *testing.T) {
+	server := featureServer(t)
+	defer server.Close()
+
+	config := do(t, http.MethodGet, server.URL+"/ServiceProviderConfig", http.StatusOK, "")
+	bulk := config["bulk"].(map[string]interface{})
+	if bulk["supported"] != true {
+		t.Errorf("expected bulk to be supported: %v", bulk)
+	}
+	maxOperations := int(bulk["maxOperations"].(float64))
+	maxPayloadSize := int(bulk["maxPayloadSize"].(float64))
+
+	operations := func(n int) string {
+		ops := make([]string, n)
+		for i := range ops {
+			ops[i] = fmt.Sprintf(`{"method": "DELETE", "path": "/Users/%d"}`, i)
+		}
+		return fmt.Sprintf(`{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": [%s]
+		}`, strings.Join(ops, ","))
+	}
+	response := do(t, http.MethodPost, server.URL+"/Bulk", http.StatusOK, operations(maxOperations))
+	if n := len(bulkOperations(response)); n != maxOperations {
+		t.Errorf("expected %d operations, got %d", maxOperations, n)
+	}
+	do(t, http.MethodPost, server.URL+"/Bulk", http.StatusRequestEntityTooLarge, operations(maxOperations+1))
+
+	do(t, http.MethodPost, server.URL+"/Bulk", http.StatusRequestEntityTooLarge, fmt.Sprintf(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": [{"method": "POST", "path": "/Users", "bulkId": "user", "data": {"userName": %q}}]
+	}`, strings.Repeat("a", maxPayloadSize)))
+
+	do(t, http.MethodPost, server.URL+"/Bulk", http.StatusBadRequest, `{"Operations": []}`)
+}
+
+// TestSort checks the sortBy and sortOrder parameters, on GET requests and searches.
+func TestSort(t *testing.T) {
+	server := featureServer(t)
+	defer server.Close()
+
+	for _, user := range []struct{ userName, nickName string }{{"b", "x"}, {"C", ""}, {"a", "y"}} {
+		body := fmt.Sprintf(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": %q}`, user.userName)
+		if user.nickName != "" {
+			body = fmt.Sprintf(`{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": %q, "nickName": %q}`, user.userName, user.nickName)
+		}
+		do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, body)
+	}
+
+	// the reference server has seed users, the filter only selects the ones of this test.
+	const filter = "filter=userName%20lt%20%22d%22&"
+	for _, test := range []struct {
+		query    string
+		expected []string
+	}{
+		// userName is case insensitive.
+		{"sortBy=userName", []string{"a", "b", "C"}},
+		{"sortBy=userName&sortOrder=ascending", []string{"a", "b", "C"}},
+		{"sortBy=userName&sortOrder=descending", []string{"C", "b", "a"}},
+		// resources without a value are sorted last, whatever the order.
+		{"sortBy=nickName", []string{"b", "a", "C"}},
+		{"sortBy=nickName&sortOrder=descending", []string{"a", "b", "C"}},
+		{"sortBy=userName&count=2", []string{"a", "b"}},
+		{"sortBy=userName&startIndex=2", []string{"b", "C"}},
+	} {
+		response := do(t, http.MethodGet, server.URL+"/Users?"+filter+test.query, http.StatusOK, "")
+		if userNames := userNames(response); strings.Join(userNames, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", test.query, test.expected, userNames)
+		}
+	}
+
+	do(t, http.MethodGet, server.URL+"/Users?sortBy=userName&sortOrder=random", http.StatusBadRequest, "")
+	do(t, http.MethodGet, server.URL+"/Users?sortBy=unknown", http.StatusBadRequest, "")
+	do(t, http.MethodGet, server.URL+"/Users?sortBy=emails[type%20eq%20%22work%22]", http.StatusBadRequest, "")
+
+	response := do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+		"filter": "userName lt \"d\"",
+		"sortBy": "userName",
+		"sortOrder": "descending"
+	}`)
+	if userNames := userNames(response); strings.Join(userNames, ",") != "C,b,a" {
+		t.Errorf("expected the search to be sorted, got %v", userNames)
+	}
+}
+
+// TestSearch checks that the parameters of a search request are applied the same way as the ones of a GET request.
+func TestSearch(t *testing.T) {
+	server := featureServer(t)
+	defer server.Close()
+
+	for _, userName := range []string{"search-a", "search-b", "other"} {
+		do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, fmt.Sprintf(`{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": %q,
+			"displayName": "Search"
+		}`, userName))
+	}
+
+	response := do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+		"filter": "userName sw \"search\"",
+		"attributes": ["userName"],
+		"startIndex": 1,
+		"count": 1
+	}`)
+	if response["totalResults"] != 2.0 {
+		t.Errorf("expected 2 results, got %v", response["totalResults"])
+	}
+	resources, _ := response["Resources"].([]interface{})
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 resource, got %v", response["Resources"])
+	}
+	if resource := resources[0].(map[string]interface{}); resource["userName"] == nil || resource["displayName"] != nil {
+		t.Errorf("expected only the requested attributes, got %v", resource)
+	}
+
+	response = do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+		"filter": "displayName eq \"Search\"",
+		"excludedAttributes": ["displayName"]
+	}`)
+	resources, _ = response["Resources"].([]interface{})
+	if len(resources) != 3 {
+		t.Fatalf("expected 3 resources, got %v", response["Resources"])
+	}
+	for _, resource := range resources {
+		if resource := resource.(map[string]interface{}); resource["userName"] == nil || resource["displayName"] != nil {
+			t.Errorf("expected the excluded attributes to be left out, got %v", resource)
+		}
+	}
+
+	do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusBadRequest, `{"filter": "userName pr"}`)
+	do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusBadRequest, `{"schemas": [`)
+
+	// searching across all resource types is not supported.
+	do(t, http.MethodPost, server.URL+"/.search", http.StatusNotImplemented, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"]
+	}`)
+}
+
+// TestDisabledFeatures checks that the optional features are not served unless they are enabled.
+func TestDisabledFeatures(t *testing.T) {
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	config := do(t, http.MethodGet, server.URL+"/ServiceProviderConfig", http.StatusOK, "")
+	for _, feature := range []string{"bulk", "sort"} {
+		if supported := config[feature].(map[string]interface{})["supported"]; supported != false {
+			t.Errorf("expected %s not to be supported, got %v", feature, supported)
+		}
+	}
+
+	do(t, http.MethodPost, server.URL+"/Bulk", http.StatusNotFound, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+		"Operations": []
+	}`)
+	do(t, http.MethodPost, server.URL+"/Users/.search", http.StatusNotFound, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"]
+	}`)
+}
+
+func userNames(response map[string]interface{}) []string {
+	var userNames []string
+	resources, _ := response["Resources"].([]interface{})
+	for _, resource := range resources {
+		userName, _ := resource.(map[string]interface{})["userName"].(string)
+		userNames = append(userNames, userName)
+	}
+	return userNames
+}
@@ -0,0 +1,311 @@
+package test
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.4.2.2
+// NOTE: the filter parser only accepts quoted compare values, so booleans, numbers and date times are compared based
+// 		 on the type of the attribute (e.g. 'active eq "true"').
+
+// match returns whether the given resource matches the filter expression.
+func (s resourceSchema) match(resource map[string]interface{}, expression filter.Expression) (bool, error) {
+	return s.evaluate(s.attributes, resource, expression, false)
+}
+
+// evaluate returns whether the given object matches the filter expression. The given attributes describe the object.
+// Within a value path (nested), the expression refers to the sub attributes of the (multi valued) attribute.
+func (s resourceSchema) evaluate(attributes []attribute, object map[string]interface{}, expression filter.Expression, nested bool) (bool, error) {
+	switch e := expression.(type) {
+	case filter.BinaryExpression:
+		x, err := s.evaluate(attributes, object, e.X, nested)
+		if err != nil {
+			return false, err
+		}
+		switch e.CompareOperator {
+		case filter.AND:
+			if !x {
+				return false, nil
+			}
+		case filter.OR:
+			if x {
+				return true, nil
+			}
+		default:
+			return false, errors.ScimErrorInvalidFilter
+		}
+		return s.evaluate(attributes, object, e.Y, nested)
+	case filter.UnaryExpression:
+		if e.CompareOperator != filter.NOT {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		x, err := s.evaluate(attributes, object, e.X, nested)
+		return !x, err
+	case filter.ValuePath:
+		if nested {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		attributes, object, ok := s.scope(e.URIPrefix, object)
+		if !ok {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		attr, ok := find(attributes, e.AttributeName)
+		if !ok || attr.Type != "complex" {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		for _, v := range values(get(object, e.AttributeName)) {
+			element, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			match, err := s.evaluate(attr.SubAttributes, element, e.ValueExpression, true)
+			if err != nil || match {
+				return match, err
+			}
+		}
+		return false, nil
+	case filter.AttributeExpression:
+		if e.AttributePath.URIPrefix != "" {
+			if nested {
+				return false, errors.ScimErrorInvalidFilter
+			}
+			var ok bool
+			if attributes, object, ok = s.scope(e.AttributePath.URIPrefix, object); !ok {
+				return false, errors.ScimErrorInvalidFilter
+			}
+		}
+		return evaluateAttribute(attributes, object, e)
+	default:
+		return false, errors.ScimErrorInvalidFilter
+	}
+}
+
+// evaluateAttribute returns whether the attribute of the given object matches the attribute expression. Multi valued
+// attributes match if any of their values match.
+func evaluateAttribute(attributes []attribute, object map[string]interface{}, e filter.AttributeExpression) (bool, error) {
+	var (
+		name    = e.AttributePath.AttributeName
+		sub     = e.AttributePath.SubAttribute
+		attr, _ = find(attributes, name)
+		vs      = values(get(object, name))
+	)
+
+	// complex attributes without a sub attribute are compared based on their "value" sub attribute.
+	if sub == "" && attr.Type == "complex" && e.CompareOperator != filter.PR {
+		if _, ok := attr.subAttribute("value"); !ok {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		sub = "value"
+	}
+	if sub != "" {
+		var subValues []interface{}
+		for _, v := range vs {
+			if m, ok := v.(map[string]interface{}); ok {
+				subValues = append(subValues, values(get(m, sub))...)
+			}
+		}
+		attr, _ = attr.subAttribute(sub)
+		vs = subValues
+	}
+
+	switch e.CompareOperator {
+	case filter.PR:
+		return len(vs) != 0, nil
+	case filter.NE:
+		// an attribute that is not present is not equal to any value.
+		eq, err := compareAny(attr, vs, filter.EQ, e.CompareValue)
+		return !eq, err
+	default:
+		return compareAny(attr, vs, e.CompareOperator, e.CompareValue)
+	}
+}
+
+func compareAny(attr attribute, vs []interface{}, operator filter.Token, value string) (bool, error) {
+	for _, v := range vs {
+		match, err := compare(attr, v, operator, value)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+// compare compares the given (singular) value with the filter value, based on the type of the attribute. Unknown
+// attributes are compared based on the type of the value.
+func compare(attr attribute, v interface{}, operator filter.Token, value string) (bool, error) {
+	typ := attr.Type
+	if typ == "" {
+		switch v.(type) {
+		case bool:
+			typ = "boolean"
+		case json.Number, float64, int:
+			typ = "decimal"
+		default:
+			typ = "string"
+		}
+	}
+
+	switch typ {
+	case "boolean":
+		if operator != filter.EQ {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		expected, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		actual, ok := toBool(v)
+		return ok && actual == expected, nil
+	case "integer", "decimal":
+		expected, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		actual, ok := toFloat(v)
+		if !ok {
+			return false, nil
+		}
+		return order(operator, compareFloat(actual, expected))
+	case "dateTime":
+		expected, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return false, errors.ScimErrorInvalidFilter
+		}
+		actual, ok := toTime(v)
+		if !ok {
+			return false, nil
+		}
+		return order(operator, compareTime(actual, expected))
+	case "complex":
+		return false, errors.ScimErrorInvalidFilter
+	default:
+		actual, ok := v.(string)
+		if !ok {
+			return false, nil
+		}
+		if !attr.CaseExact {
+			actual, value = strings.ToLower(actual), strings.ToLower(value)
+		}
+		switch operator {
+		case filter.CO:
+			return strings.Contains(actual, value), nil
+		case filter.SW:
+			return strings.HasPrefix(actual, value), nil
+		case filter.EW:
+			return strings.HasSuffix(actual, value), nil
+		default:
+			return order(operator, strings.Compare(actual, value))
+		}
+	}
+}
+
+// order returns whether the result of a comparison (-1, 0 or +1) satisfies the operator.
+func order(operator filter.Token, cmp int) (bool, error) {
+	switch operator {
+	case filter.EQ:
+		return cmp == 0, nil
+	case filter.GT:
+		return cmp > 0, nil
+	case filter.GE:
+		return cmp >= 0, nil
+	case filter.LT:
+		return cmp < 0, nil
+	case filter.LE:
+		return cmp <= 0, nil
+	default:
+		return false, errors.ScimErrorInvalidFilter
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// values returns the values of a (multi valued) attribute, nil values are left out.
+func values(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var vs []interface{}
+		for _, e := range v {
+			if e != nil {
+				vs = append(vs, e)
+			}
+		}
+		return vs
+	case []map[string]interface{}:
+		var vs []interface{}
+		for _, e := range v {
+			vs = append(vs, e)
+		}
+		return vs
+	default:
+		return []interface{}{v}
+	}
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
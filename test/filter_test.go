@@ -0,0 +1,151 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim/schema"
+)
+
+func filterResource() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "0001",
+		"externalId":  "External",
+		"userName":    "BJensen",
+		"displayName": "Babs Jensen",
+		"active":      true,
+		"title":       nil,
+		"name": map[string]interface{}{
+			"familyName": "Jensen",
+			"givenName":  "Barbara",
+		},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "bjensen@example.com", "type": "work", "primary": true},
+			map[string]interface{}{"value": "babs@jensen.org", "type": "home"},
+		},
+		"meta": map[string]interface{}{
+			"resourceType": "User",
+			"lastModified": "2020-08-01T12:00:00Z",
+		},
+		// unknown attributes are compared based on the type of their value.
+		"loginCount": json.Number("5"),
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]interface{}{
+			"employeeNumber": "701984",
+			"manager":        map[string]interface{}{"value": "0002"},
+		},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s := newResourceSchema(schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+	for _, test := range []struct {
+		filter string
+		match  bool
+	}{
+		// string operators, userName is case insensitive.
+		{`userName eq "bjensen"`, true},
+		{`userName eq "jensen"`, false},
+		{`userName co "JENS"`, true},
+		{`userName sw "bj"`, true},
+		{`userName sw "jensen"`, false},
+		{`userName ew "SEN"`, true},
+		{`userName gt "a"`, true},
+		{`userName ge "bjensen"`, true},
+		{`userName lt "bjensen"`, false},
+		{`userName le "c"`, true},
+		// externalId and id are case exact.
+		{`externalId eq "External"`, true},
+		{`externalId eq "external"`, false},
+		{`id eq "0001"`, true},
+		// ne matches attributes that are not present.
+		{`userName ne "bjensen"`, false},
+		{`userName ne "other"`, true},
+		{`nickName ne "other"`, true},
+		// pr
+		{`userName pr`, true},
+		{`nickName pr`, false},
+		{`title pr`, false},
+		{`emails pr`, true},
+		{`name.middleName pr`, false},
+		// sub attributes and multi valued attributes.
+		{`name.familyName eq "jensen"`, true},
+		{`emails.type eq "home"`, true},
+		{`emails.type eq "other"`, false},
+		{`emails co "example.com"`, true},
+		// booleans
+		{`active eq "true"`, true},
+		{`active eq "false"`, false},
+		{`emails.primary eq "true"`, true},
+		// date times
+		{`meta.lastModified gt "2020-07-31T00:00:00Z"`, true},
+		{`meta.lastModified lt "2020-07-31T00:00:00Z"`, false},
+		{`meta.lastModified ge "2020-08-01T12:00:00Z"`, true},
+		{`meta.lastModified le "2020-08-01T11:59:59Z"`, false},
+		{`meta.lastModified eq "2020-08-01T14:00:00+02:00"`, true},
+		// numbers
+		{`loginCount gt "4"`, true},
+		{`loginCount ge "5.0"`, true},
+		{`loginCount lt "5"`, false},
+		{`loginCount eq "5"`, true},
+		// logical operators
+		{`userName eq "bjensen" and active eq "true"`, true},
+		{`userName eq "bjensen" and active eq "false"`, false},
+		{`userName eq "other" or active eq "true"`, true},
+		{`userName eq "other" or active eq "false"`, false},
+		{`not (userName eq "other")`, true},
+		{`not (userName eq "bjensen")`, false},
+		{`userName eq "other" or (active eq "true" and not (title pr))`, true},
+		// value paths match if one of the values matches the whole expression.
+		{`emails[type eq "work" and value co "example.com"]`, true},
+		{`emails[type eq "home" and value co "example.com"]`, false},
+		{`emails[type eq "home"] and userName sw "b"`, true},
+		// URN qualified attributes.
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "701984"`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "1"`, false},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value eq "0002"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`, true},
+	} {
+		expression, err := filter.NewParser(strings.NewReader(test.filter)).Parse()
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		match, err := s.match(filterResource(), expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.filter, err)
+			continue
+		}
+		if match != test.match {
+			t.Errorf("%s: expected %v, got %v", test.filter, test.match, match)
+		}
+	}
+}
+
+func TestInvalidFilter(t *testing.T) {
+	s := newResourceSchema(schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+	for _, f := range []string{
+		// booleans can only be compared for equality.
+		`active gt "true"`,
+		`active eq "yes"`,
+		`meta.lastModified gt "yesterday"`,
+		`loginCount gt "many"`,
+		// complex attributes can not be compared without a sub attribute, unless they have a value.
+		`name eq "Jensen"`,
+		// value paths can only filter complex attributes and can not be nested.
+		`userName[value eq "bjensen"]`,
+		`emails[type eq "work" and emails[type eq "home"]]`,
+		`emails[urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"]`,
+		`urn:ietf:params:scim:schemas:extension:unknown:2.0:User:x eq "y"`,
+	} {
+		expression, err := filter.NewParser(strings.NewReader(f)).Parse()
+		if err != nil {
+			// rejected by the parser.
+			continue
+		}
+		if _, err := s.match(filterResource(), expression); err == nil {
+			t.Errorf("%s: expected an error", f)
+		}
+	}
+}
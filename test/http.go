@@ -0,0 +1,344 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+)
+
+const (
+	patchOpSchema       = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	searchRequestSchema = "urn:ietf:params:scim:api:messages:2.0:SearchRequest"
+)
+
+// ReferenceServer is the in-memory reference SCIM server. Requests are served by the scim package, except for the
+// ones it does not handle according to the spec (e.g. PATCH) and the ones of the optional features.
+type ReferenceServer struct {
+	scim.Server
+	// Features are read by every request without synchronization, they have to be set before the server serves
+	// requests.
+	Features *Features
+
+	limiter *rateLimiter
+}
+
+// Features are the optional features of the reference server that are not supported by the scim package. Enabled
+// features are advertised in the service provider configuration.
+type Features struct {
+	// Bulk enables the "/Bulk" endpoint.
+	Bulk bool
+	// Search enables the "/.search" endpoints of the resource types, the root "/.search" returns 501 (Not Implemented).
+	Search bool
+	// Sort enables the "sortBy" and "sortOrder" parameters.
+	Sort bool
+	// ETag enables the "If-Match" header on PUT, PATCH and DELETE requests.
+	ETag bool
+	// ChangePassword allows the password of a user to be changed.
+	ChangePassword bool
+	// Reset enables the "/Reset" endpoint, which replaces all resources with the generated test data. It is not part
+	// of SCIM and is not advertised.
+	Reset bool
+	// RateLimit is the maximum number of requests per second, requests beyond it get a 429 (Too Many Requests). There
+	// is no limit if it is zero.
+	RateLimit int
+}
+
+// ServeHTTP serves the request if it is within the rate limit. Requests that are part of another request (e.g. the
+// operations of a bulk request) are not limited on their own.
+func (s ReferenceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Features.RateLimit > 0 {
+		if ok, delay := s.limiter.allow(s.Features.RateLimit); !ok {
+			tooManyRequests(w, delay)
+			return
+		}
+	}
+	s.handle(w, r)
+}
+
+// handle serves the request and applies its "attributes" and "excludedAttributes" parameters.
+func (s ReferenceServer) handle(w http.ResponseWriter, r *http.Request) {
+	if query := r.URL.Query(); query.Get("attributes") != "" || query.Get("excludedAttributes") != "" {
+		s.partialResponse(w, r)
+		return
+	}
+	s.serve(w, r)
+}
+
+// serve serves the request without applying the "attributes" and "excludedAttributes" parameters.
+func (s ReferenceServer) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v2")
+	switch {
+	case path == "/ServiceProviderConfig" && r.Method == http.MethodGet:
+		s.serviceProviderConfig(w)
+		return
+	case path == "/Bulk" && r.Method == http.MethodPost && s.Features.Bulk:
+		s.bulk(w, r)
+		return
+	case path == "/Reset" && r.Method == http.MethodPost && s.Features.Reset:
+		s.reset(w)
+		return
+	case path == "/.search" && r.Method == http.MethodPost && s.Features.Search:
+		writeError(w, errors.ScimError{
+			Detail: "Searching across all resource types is not supported.",
+			Status: http.StatusNotImplemented,
+		})
+		return
+	}
+
+	for _, resourceType := range s.ResourceTypes {
+		if path == resourceType.Endpoint && r.Method == http.MethodPost {
+			s.create(w, r, resourceType)
+			return
+		}
+		if !strings.HasPrefix(path, resourceType.Endpoint+"/") {
+			continue
+		}
+		if path == resourceType.Endpoint+"/.search" && r.Method == http.MethodPost && s.Features.Search {
+			s.search(w, r)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			id, err := url.PathUnescape(strings.TrimPrefix(path, resourceType.Endpoint+"/"))
+			if err != nil || id == "" || strings.Contains(id, "/") {
+				break
+			}
+			s.patch(w, r, resourceType, id)
+			return
+		}
+	}
+	s.Server.ServeHTTP(w, r)
+}
+
+// create handles POST requests to the endpoint of a resource type, it adds the Location header that the scim package
+// leaves out.
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.3
+func (s ReferenceServer) create(w http.ResponseWriter, r *http.Request, resourceType scim.ResourceType) {
+	rec := httptest.NewRecorder()
+	s.Server.ServeHTTP(rec, r)
+
+	if rec.Code == http.StatusCreated {
+		var resource struct {
+			ID string
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resource); err == nil {
+			rec.Header().Set("Location", absoluteURL(r, fmt.Sprintf("%s/%s", r.URL.Path, url.PathEscape(resource.ID))))
+		}
+	}
+	copyResponse(w, rec)
+}
+
+// serviceProviderConfig writes the service provider configuration, including the optional features.
+// RFC: https://tools.ietf.org/html/rfc7643#section-5
+func (s ReferenceServer) serviceProviderConfig(w http.ResponseWriter) {
+	maxResults := s.Config.MaxResults
+	if maxResults < 1 {
+		maxResults = 100
+	}
+
+	authenticationSchemes := make([]map[string]interface{}, 0)
+	for _, scheme := range s.Config.AuthenticationSchemes {
+		authenticationSchemes = append(authenticationSchemes, map[string]interface{}{
+			"type":             scheme.Type,
+			"name":             scheme.Name,
+			"description":      scheme.Description,
+			"specUri":          scheme.SpecURI.Value(),
+			"documentationUri": scheme.DocumentationURI.Value(),
+			"primary":          scheme.Primary,
+		})
+	}
+
+	write(w, http.StatusOK, map[string]interface{}{
+		"schemas":          []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"documentationUri": s.Config.DocumentationURI.Value(),
+		"patch": map[string]bool{
+			"supported": s.Config.SupportPatch,
+		},
+		"bulk": map[string]interface{}{
+			"supported":      s.Features.Bulk,
+			"maxOperations":  maxOperations,
+			"maxPayloadSize": maxPayloadSize,
+		},
+		"filter": map[string]interface{}{
+			"supported":  s.Config.SupportFiltering,
+			"maxResults": maxResults,
+		},
+		"changePassword": map[string]bool{
+			"supported": s.Features.ChangePassword,
+		},
+		"sort": map[string]bool{
+			"supported": s.Features.Sort,
+		},
+		"etag": map[string]bool{
+			"supported": s.Features.ETag,
+		},
+		"authenticationSchemes": authenticationSchemes,
+	})
+}
+
+// search handles POST requests to the "/.search" endpoint of a resource type, by serving them as GET requests.
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.4.3
+func (s ReferenceServer) search(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Schemas            []string
+		Attributes         []string
+		ExcludedAttributes []string
+		Filter             string
+		SortBy             string
+		SortOrder          string
+		StartIndex         int
+		Count              *int
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !contains(req.Schemas, searchRequestSchema) {
+		writeError(w, errors.ScimErrorInvalidSyntax)
+		return
+	}
+
+	query := make(url.Values)
+	for k, v := range map[string]string{
+		"attributes":         strings.Join(req.Attributes, ","),
+		"excludedAttributes": strings.Join(req.ExcludedAttributes, ","),
+		"filter":             req.Filter,
+		"sortBy":             req.SortBy,
+		"sortOrder":          req.SortOrder,
+	} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	if req.StartIndex != 0 {
+		query.Set("startIndex", strconv.Itoa(req.StartIndex))
+	}
+	if req.Count != nil {
+		query.Set("count", strconv.Itoa(*req.Count))
+	}
+
+	get := r.Clone(r.Context())
+	get.Method = http.MethodGet
+	get.Body = http.NoBody
+	get.URL.Path = strings.TrimSuffix(r.URL.Path, "/.search")
+	get.URL.RawQuery = query.Encode()
+	s.handle(w, get)
+}
+
+// patch handles PATCH requests, the operations are applied by the resource handler without being validated first.
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.5.2
+func (s ReferenceServer) patch(w http.ResponseWriter, r *http.Request, resourceType scim.ResourceType, id string) {
+	var req scim.PatchRequest
+	d := json.NewDecoder(r.Body)
+	d.UseNumber()
+	if err := d.Decode(&req); err != nil {
+		writeError(w, errors.ScimErrorInvalidSyntax)
+		return
+	}
+
+	if !contains(req.Schemas, patchOpSchema) {
+		writeError(w, errors.ScimErrorInvalidSyntax)
+		return
+	}
+	if len(req.Operations) == 0 {
+		writeError(w, errors.ScimErrorInvalidValue)
+		return
+	}
+
+	resource, err := resourceType.Handler.Patch(r, id, req)
+	if err != nil {
+		writeError(w, errors.CheckScimError(err, http.MethodPatch))
+		return
+	}
+	if h, ok := resourceType.Handler.(*ResourceHandler); ok && h == h.groups && r.URL.Query().Get("attributes") == "" {
+		// the members of a group are only returned when they are requested, large groups would otherwise be sent in
+		// full on every change.
+		remove(resource.Attributes, "members")
+	}
+	writeResource(w, http.StatusOK, resourceType, resource)
+}
+
+// writeResource writes the resource the same way the scim package does.
+func writeResource(w http.ResponseWriter, status int, resourceType scim.ResourceType, resource scim.Resource) {
+	response := resource.Attributes
+	response["id"] = resource.ID
+	if resource.ExternalID.Present() {
+		response["externalId"] = resource.ExternalID.Value()
+	}
+
+	schemas := []string{resourceType.Schema.ID}
+	for _, extension := range resourceType.SchemaExtensions {
+		schemas = append(schemas, extension.Schema.ID)
+	}
+	response["schemas"] = schemas
+
+	meta := map[string]interface{}{
+		"resourceType": resourceType.Name,
+		"location":     fmt.Sprintf("%s/%s", resourceType.Endpoint[1:], url.PathEscape(resource.ID)),
+	}
+	if resource.Meta.Created != nil {
+		meta["created"] = resource.Meta.Created.Format(time.RFC3339)
+	}
+	if resource.Meta.LastModified != nil {
+		meta["lastModified"] = resource.Meta.LastModified.Format(time.RFC3339)
+	}
+	if resource.Meta.Version != "" {
+		meta["version"] = resource.Meta.Version
+		w.Header().Set("Etag", resource.Meta.Version)
+	}
+	response["meta"] = meta
+
+	write(w, status, response)
+}
+
+// absoluteURL returns the absolute URL of the given path on the host of the request.
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path)
+}
+
+// copyResponse writes the recorded response to the given writer.
+func copyResponse(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.Code)
+	if _, err := w.Write(rec.Body.Bytes()); err != nil {
+		log.Printf("failed writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, scimErr errors.ScimError) {
+	write(w, scimErr.Status, scimErr)
+}
+
+func write(w http.ResponseWriter, status int, v interface{}) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("failed marshaling response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	if _, err := w.Write(raw); err != nil {
+		log.Printf("failed writing response: %v", err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
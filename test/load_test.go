@@ -0,0 +1,37 @@
+package test_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/di-wu/scim-test-suite/load"
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+// TestLoad runs the load mode against the reference server, every endpoint gets traffic without any errors.
+func TestLoad(t *testing.T) {
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	report := load.Run(context.Background(), load.Config{
+		URL:         server.URL,
+		Rate:        500,
+		Duration:    200 * time.Millisecond,
+		Concurrency: 4,
+	})
+	for _, endpoint := range []string{
+		load.CreateUser, load.GetUser, load.ListUsers, load.PatchUser, load.DeleteUser,
+		load.CreateGroup, load.GetGroup, load.PatchGroup, load.DeleteGroup,
+	} {
+		stats, ok := report.Endpoints[endpoint]
+		if !ok || stats.Requests == 0 {
+			t.Errorf("%s: no requests", endpoint)
+			continue
+		}
+		if stats.Errors != 0 {
+			t.Errorf("%s: %d error(s)", endpoint, stats.Errors)
+		}
+	}
+}
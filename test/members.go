@@ -0,0 +1,214 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7643#section-4.2
+
+// Members of a group are stored with their "value" and "type" only, their "$ref" and "display" are added when the
+// group gets returned. The "groups" of a user are never stored, they are derived from the members of the groups.
+// Members can only refer to resources of the "/Users" and "/Groups" endpoints.
+
+const (
+	memberTypeUser  = "User"
+	memberTypeGroup = "Group"
+)
+
+// resolveMembers checks that the members of the given group refer to existing users or groups and replaces them with
+// their value and type. Duplicate members are removed. The caller must hold the lock.
+func (h *ResourceHandler) resolveMembers(id string, attributes scim.ResourceAttributes) error {
+	if h != h.groups {
+		return nil
+	}
+	members, ok := get(attributes, "members").([]interface{})
+	if !ok {
+		if get(attributes, "members") != nil {
+			return errors.ScimErrorInvalidValue
+		}
+		return nil
+	}
+
+	var (
+		resolved = make([]interface{}, 0, len(members))
+		seen     = make(map[string]bool)
+	)
+	for _, m := range members {
+		member, _ := m.(map[string]interface{})
+		value, _ := get(member, "value").(string)
+		if value == "" {
+			return errors.ScimErrorInvalidValue
+		}
+		memberType, _ := get(member, "type").(string)
+		switch {
+		case (memberType == "" || strings.EqualFold(memberType, memberTypeUser)) && h.users.exists(value):
+			memberType = memberTypeUser
+		case (memberType == "" || strings.EqualFold(memberType, memberTypeGroup)) && h.groups.exists(value) && value != id:
+			memberType = memberTypeGroup
+		default:
+			return errors.ScimError{
+				ScimType: errors.ScimTypeInvalidValue,
+				Detail:   fmt.Sprintf("The member %q does not refer to an existing user or group.", value),
+				Status:   http.StatusBadRequest,
+			}
+		}
+		if seen[memberType+value] {
+			continue
+		}
+		seen[memberType+value] = true
+		resolved = append(resolved, map[string]interface{}{
+			"value": value,
+			"type":  memberType,
+		})
+	}
+	setOrRemove(attributes, "members", resolved)
+	return nil
+}
+
+// removeMember removes the given user or group from all groups it is a member of, the caller must hold the lock.
+func (h *ResourceHandler) removeMember(id string) {
+	var memberType string
+	switch h {
+	case h.users:
+		memberType = memberTypeUser
+	case h.groups:
+		memberType = memberTypeGroup
+	default:
+		return
+	}
+
+	var changed bool
+	for _, groupID := range h.groups.ids {
+		data := h.groups.data[groupID]
+		members, _ := get(data.resourceAttributes, "members").([]interface{})
+		remaining := make([]interface{}, 0, len(members))
+		for _, m := range members {
+			if member, _ := m.(map[string]interface{}); member["value"] == id && member["type"] == memberType {
+				continue
+			}
+			remaining = append(remaining, m)
+		}
+		if len(remaining) == len(members) {
+			continue
+		}
+
+		setOrRemove(data.resourceAttributes, "members", remaining)
+		data.meta.lastModified = time.Now().UTC()
+		h.groups.store(groupID, data)
+		changed = true
+	}
+	if changed {
+		h.groups.save()
+	}
+}
+
+// exists returns whether the handler has a resource with the given identifier, the caller must hold the lock.
+func (h *ResourceHandler) exists(id string) bool {
+	_, ok := h.data[id]
+	return ok
+}
+
+// display returns the human-readable name of the resource, the caller must hold the lock.
+func (h *ResourceHandler) display(id string) string {
+	attributes := h.data[id].resourceAttributes
+	if displayName, ok := get(attributes, "displayName").(string); ok && displayName != "" {
+		return displayName
+	}
+	userName, _ := get(attributes, "userName").(string)
+	return userName
+}
+
+// references adds the "$ref" and "display" of the members of a group, or the "groups" of a user, to the given
+// attributes. The caller must hold the lock.
+func (h *ResourceHandler) references(r *http.Request, id string, attributes scim.ResourceAttributes) {
+	switch h {
+	case h.groups:
+		members, _ := get(attributes, "members").([]interface{})
+		for _, m := range members {
+			member, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			value, _ := member["value"].(string)
+			if member["type"] == memberTypeGroup {
+				member["$ref"] = reference(r, "/Groups", value)
+				member["display"] = h.groups.display(value)
+				continue
+			}
+			member["$ref"] = reference(r, "/Users", value)
+			member["display"] = h.users.display(value)
+		}
+	case h.users:
+		groups := h.memberOf(id)
+		for _, g := range groups {
+			group := g.(map[string]interface{})
+			group["$ref"] = reference(r, "/Groups", group["value"].(string))
+		}
+		setOrRemove(attributes, "groups", groups)
+	}
+}
+
+// memberOf returns the groups the given user is a member of, either directly or through nested groups. The caller
+// must hold the lock.
+func (h *ResourceHandler) memberOf(userID string) []interface{} {
+	var (
+		groups  = make([]interface{}, 0)
+		visited = make(map[string]bool)
+		queue   = []string{userID}
+	)
+	for memberType := memberTypeUser; len(queue) != 0; memberType = memberTypeGroup {
+		var next []string
+		for _, groupID := range h.groups.ids {
+			if visited[groupID] || !h.groups.hasMember(groupID, memberType, queue) {
+				continue
+			}
+			visited[groupID] = true
+			next = append(next, groupID)
+
+			membership := "indirect"
+			if memberType == memberTypeUser {
+				membership = "direct"
+			}
+			groups = append(groups, map[string]interface{}{
+				"value":   groupID,
+				"display": h.groups.display(groupID),
+				"type":    membership,
+			})
+		}
+		queue = next
+	}
+	return groups
+}
+
+// hasMember returns whether one of the given resources is a member of the group, the caller must hold the lock.
+func (h *ResourceHandler) hasMember(groupID, memberType string, ids []string) bool {
+	members, _ := get(h.data[groupID].resourceAttributes, "members").([]interface{})
+	for _, m := range members {
+		member, _ := m.(map[string]interface{})
+		if member["type"] != memberType {
+			continue
+		}
+		for _, id := range ids {
+			if member["value"] == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reference returns the URI of the resource with the given identifier, relative to the base of the request.
+func reference(r *http.Request, endpoint, id string) string {
+	base := ""
+	if strings.HasPrefix(r.URL.Path, "/v2/") {
+		base = "/v2"
+	}
+	return absoluteURL(r, fmt.Sprintf("%s%s/%s", base, endpoint, url.PathEscape(id)))
+}
@@ -0,0 +1,127 @@
+package test_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+// TestMembers checks that members refer to existing resources, that the groups of a user are derived from the
+// members of the groups and that memberships get removed together with the user.
+func TestMembers(t *testing.T) {
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	user := do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "member",
+		"displayName": "Member"
+	}`)
+	userID := user["id"].(string)
+
+	do(t, http.MethodPost, server.URL+"/Groups", http.StatusBadRequest, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "unknown",
+		"members": [{"value": "unknown"}]
+	}`)
+
+	group := do(t, http.MethodPost, server.URL+"/Groups", http.StatusCreated, fmt.Sprintf(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "direct",
+		"members": [{"value": %q}]
+	}`, userID))
+	groupID := group["id"].(string)
+	member := group["members"].([]interface{})[0].(map[string]interface{})
+	if member["display"] != "Member" || member["type"] != "User" || member["$ref"] != server.URL+"/Users/"+userID {
+		t.Errorf("unexpected member: %v", member)
+	}
+
+	nested := do(t, http.MethodPost, server.URL+"/Groups", http.StatusCreated, fmt.Sprintf(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "indirect",
+		"members": [{"value": %q, "type": "Group"}]
+	}`, groupID))
+	nestedID := nested["id"].(string)
+
+	user = do(t, http.MethodGet, server.URL+"/Users/"+userID, http.StatusOK, "")
+	groups, _ := user["groups"].([]interface{})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %v", user["groups"])
+	}
+	for i, expected := range []struct{ id, membership string }{{groupID, "direct"}, {nestedID, "indirect"}} {
+		if g := groups[i].(map[string]interface{}); g["value"] != expected.id || g["type"] != expected.membership {
+			t.Errorf("unexpected group: %v", g)
+		}
+	}
+
+	do(t, http.MethodDelete, server.URL+"/Users/"+userID, http.StatusNoContent, "")
+	group = do(t, http.MethodGet, server.URL+"/Groups/"+groupID, http.StatusOK, "")
+	if _, ok := group["members"]; ok {
+		t.Errorf("expected the membership of the deleted user to be removed: %v", group["members"])
+	}
+}
+
+// do sends a request with the given body and returns the response body, it fails if the status code is unexpected.
+func do(t *testing.T, method, url string, status int, body string) map[string]interface{} {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/scim+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: expected status code %d, got %d", method, url, status, resp.StatusCode)
+	}
+
+	var resource map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&resource)
+	return resource
+}
+
+// TestPatchMembers checks that the members of a group are only returned on a PATCH if they are requested, while the
+// response of other resource types is left intact.
+func TestPatchMembers(t *testing.T) {
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	user := do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "patched",
+		"displayName": "Patched",
+		"emails": [{"value": "patched@example.com"}]
+	}`)
+	userID := user["id"].(string)
+	group := do(t, http.MethodPost, server.URL+"/Groups", http.StatusCreated, fmt.Sprintf(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "patched",
+		"members": [{"value": %q}]
+	}`, userID))
+	groupID := group["id"].(string)
+
+	patch := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "displayName", "value": "Renamed"}]
+	}`
+	group = do(t, http.MethodPatch, server.URL+"/Groups/"+groupID, http.StatusOK, patch)
+	if _, ok := group["members"]; ok || group["displayName"] != "Renamed" {
+		t.Errorf("expected the group without members, got %v", group)
+	}
+	group = do(t, http.MethodPatch, server.URL+"/Groups/"+groupID+"?attributes=members", http.StatusOK, patch)
+	if members, _ := group["members"].([]interface{}); len(members) != 1 {
+		t.Errorf("expected the requested members, got %v", group)
+	}
+
+	user = do(t, http.MethodPatch, server.URL+"/Users/"+userID, http.StatusOK, patch)
+	if emails, _ := user["emails"].([]interface{}); len(emails) != 1 || user["displayName"] != "Renamed" || user["groups"] == nil {
+		t.Errorf("expected the full user, got %v", user)
+	}
+}
@@ -0,0 +1,24 @@
+package test_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/idp/okta"
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/stretchr/testify/suite"
+)
+
+// TestOktaPasswordSync runs the Okta suite against the reference server with password changes enabled, so the
+// password push is not skipped.
+func TestOktaPasswordSync(t *testing.T) {
+	s := test.Server()
+	s.Features.ChangePassword = true
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	oktaSuite := new(okta.TestSuite)
+	oktaSuite.BaseURL(server.URL)
+	oktaSuite.Strict(true)
+	suite.Run(t, oktaSuite)
+}
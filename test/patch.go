@@ -0,0 +1,520 @@
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	filter "github.com/di-wu/scim-filter-parser"
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.5.2
+
+// patchPath is a parsed PATCH path.
+//
+//	PATH = attrPath / valuePath [subAttr]
+type patchPath struct {
+	// uri is the schema the attribute belongs to, empty for the core schema.
+	uri string
+	// attribute is empty if the path refers to an extension as a whole.
+	attribute    string
+	subAttribute string
+	// filter selects values of a multi valued attribute, nil if the path is not a value path.
+	filter filter.Expression
+}
+
+// parsePath parses the given PATCH path.
+func (s resourceSchema) parsePath(p string) (patchPath, error) {
+	var (
+		path patchPath
+		// sub is true if the path has a sub attribute, which can not be empty.
+		sub bool
+	)
+	if strings.HasPrefix(strings.ToLower(p), "urn:") {
+		uri, ok := s.uri(p)
+		if !ok {
+			return path, errors.ScimErrorInvalidPath
+		}
+		path.uri = uri
+		p = strings.TrimPrefix(p[len(uri):], ":")
+		if p == "" {
+			return path, nil
+		}
+	}
+
+	if i := strings.Index(p, "["); i != -1 {
+		j := strings.LastIndex(p, "]")
+		if j < i {
+			return path, errors.ScimErrorInvalidPath
+		}
+		expression, err := filter.NewParser(strings.NewReader(p[i+1 : j])).Parse()
+		if err != nil {
+			return path, errors.ScimErrorInvalidPath
+		}
+		path.filter = expression
+
+		if rest := p[j+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return path, errors.ScimErrorInvalidPath
+			}
+			path.subAttribute, sub = rest[1:], true
+		}
+		p = p[:i]
+	} else if i := strings.Index(p, "."); i != -1 {
+		path.subAttribute, sub = p[i+1:], true
+		p = p[:i]
+	}
+	path.attribute = p
+
+	if !validName(path.attribute) || (sub && !validName(path.subAttribute)) {
+		return path, errors.ScimErrorInvalidPath
+	}
+	return path, nil
+}
+
+// validName returns whether the given name is a valid attribute name.
+//
+//	ATTRNAME = ALPHA *(nameChar)
+//	nameChar = "-" / "_" / DIGIT / ALPHA
+func validName(name string) bool {
+	if name == "$ref" {
+		return true
+	}
+	for i, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i != 0 && ('0' <= r && r <= '9' || r == '-' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// uri returns the id of the (core or extension) schema that prefixes the given path.
+func (s resourceSchema) uri(p string) (string, bool) {
+	ids := []string{s.id}
+	for id := range s.extensions {
+		ids = append(ids, id)
+	}
+
+	var uri string
+	for _, id := range ids {
+		if len(id) <= len(uri) || len(p) < len(id) || !strings.EqualFold(p[:len(id)], id) {
+			continue
+		}
+		if len(p) == len(id) || p[len(id)] == ':' {
+			uri = id
+		}
+	}
+	return uri, uri != ""
+}
+
+// patch applies the operations on the given resource, in order.
+func (s resourceSchema) patch(resource map[string]interface{}, operations []scim.PatchOperation) error {
+	for _, op := range operations {
+		if err := s.apply(resource, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutID removes the unchanged id of the resource from the values of the operations without path, Okta sends it.
+func withoutID(id string, operations []scim.PatchOperation) []scim.PatchOperation {
+	patched := make([]scim.PatchOperation, len(operations))
+	for i, op := range operations {
+		if m, ok := op.Value.(map[string]interface{}); ok && op.Path == "" && m["id"] == id {
+			value := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				if k != "id" {
+					value[k] = v
+				}
+			}
+			op.Value = value
+		}
+		patched[i] = op
+	}
+	return patched
+}
+
+func (s resourceSchema) apply(resource map[string]interface{}, op, p string, value interface{}) error {
+	switch op {
+	case scim.PatchOperationAdd, scim.PatchOperationReplace, scim.PatchOperationRemove:
+	default:
+		return errors.ScimErrorInvalidSyntax
+	}
+
+	if p == "" {
+		// the path of a remove operation is required.
+		if op == scim.PatchOperationRemove {
+			return errors.ScimErrorNoTarget
+		}
+		// the value of an add or replace operation without path holds the attributes to patch.
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return errors.ScimErrorInvalidValue
+		}
+		for k, v := range m {
+			if err := s.apply(resource, op, k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := s.parsePath(p)
+	if err != nil {
+		return err
+	}
+
+	if path.attribute == "" {
+		if path.uri == s.id {
+			return errors.ScimErrorInvalidPath
+		}
+		if op == scim.PatchOperationRemove {
+			remove(resource, path.uri)
+			return nil
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return errors.ScimErrorInvalidValue
+		}
+		for k, v := range m {
+			if err := s.apply(resource, op, path.uri+":"+k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	attributes, object, _ := s.scope(path.uri, resource)
+	attr, ok := find(attributes, path.attribute)
+	if !ok {
+		return errors.ScimErrorInvalidPath
+	}
+	if object == nil {
+		// the extension is not present (yet).
+		if op == scim.PatchOperationRemove {
+			return nil
+		}
+		object = make(map[string]interface{})
+		set(resource, path.uri, object)
+	}
+
+	switch {
+	case path.filter != nil:
+		return s.patchValues(object, attr, op, path, value)
+	case path.subAttribute != "":
+		return patchSubAttribute(object, attr, op, path.subAttribute, value)
+	default:
+		return patchAttribute(object, attr, op, value)
+	}
+}
+
+// patchAttribute patches the attribute as a whole.
+func patchAttribute(object map[string]interface{}, attr attribute, op string, value interface{}) error {
+	current := get(object, attr.Name)
+	if err := mutable(attr, op, current); err != nil {
+		return err
+	}
+
+	if op == scim.PatchOperationRemove {
+		remove(object, attr.Name)
+		return nil
+	}
+
+	v, err := attr.validate(value)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case attr.MultiValued && op == scim.PatchOperationAdd:
+		// new values are added to the existing ones, unless they are already present.
+		vs := values(current)
+		for _, e := range v.([]interface{}) {
+			if !containsValue(vs, e) {
+				vs = append(vs, e)
+			}
+		}
+		v = vs
+	case !attr.MultiValued && attr.Type == "complex":
+		// sub attributes that are not specified are left unchanged.
+		merged, _ := clone(current).(map[string]interface{})
+		if merged == nil {
+			merged = make(map[string]interface{})
+		}
+		for k, e := range v.(map[string]interface{}) {
+			set(merged, k, e)
+		}
+		v = merged
+	}
+	set(object, attr.Name, v)
+	return nil
+}
+
+// patchSubAttribute patches a sub attribute of a complex attribute. In case of a multi valued attribute, the sub
+// attribute of every value gets patched.
+func patchSubAttribute(object map[string]interface{}, attr attribute, op, name string, value interface{}) error {
+	sub, ok := attr.subAttribute(name)
+	if !ok || attr.Type != "complex" {
+		return errors.ScimErrorInvalidPath
+	}
+	if err := mutable(attr, op, nil); err != nil {
+		return err
+	}
+
+	if !attr.MultiValued {
+		complexValue, _ := get(object, attr.Name).(map[string]interface{})
+		if complexValue == nil {
+			if op == scim.PatchOperationRemove {
+				return nil
+			}
+			complexValue = make(map[string]interface{})
+		}
+		if err := patchAttribute(complexValue, sub, op, value); err != nil {
+			return err
+		}
+		setOrRemove(object, attr.Name, complexValue)
+		return nil
+	}
+
+	vs := values(get(object, attr.Name))
+	if len(vs) == 0 {
+		if op == scim.PatchOperationRemove {
+			return nil
+		}
+		return errors.ScimErrorNoTarget
+	}
+	for _, v := range vs {
+		element, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := patchAttribute(element, sub, op, value); err != nil {
+			return err
+		}
+	}
+	set(object, attr.Name, vs)
+	return nil
+}
+
+// patchValues patches the values of a multi valued attribute that match the filter of the path.
+func (s resourceSchema) patchValues(object map[string]interface{}, attr attribute, op string, path patchPath, value interface{}) error {
+	if !attr.MultiValued || attr.Type != "complex" {
+		return errors.ScimErrorInvalidPath
+	}
+	if err := mutable(attr, op, nil); err != nil {
+		return err
+	}
+
+	var (
+		sub     attribute
+		vs      = values(get(object, attr.Name))
+		patched []interface{}
+		matched bool
+	)
+	if path.subAttribute != "" {
+		var ok bool
+		if sub, ok = attr.subAttribute(path.subAttribute); !ok {
+			return errors.ScimErrorInvalidPath
+		}
+	}
+
+	for _, v := range vs {
+		element, ok := v.(map[string]interface{})
+		if !ok {
+			patched = append(patched, v)
+			continue
+		}
+		match, err := s.evaluate(attr.SubAttributes, element, path.filter, true)
+		if err != nil {
+			return errors.ScimErrorInvalidPath
+		}
+		if !match {
+			patched = append(patched, v)
+			continue
+		}
+		matched = true
+
+		if path.subAttribute != "" {
+			if err := patchAttribute(element, sub, op, value); err != nil {
+				return err
+			}
+			patched = append(patched, element)
+			continue
+		}
+
+		switch op {
+		case scim.PatchOperationRemove:
+			// leave the value out.
+		case scim.PatchOperationAdd:
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return errors.ScimErrorInvalidValue
+			}
+			for k, e := range m {
+				sub, ok := attr.subAttribute(k)
+				if !ok {
+					sub = attribute{Name: k}
+				}
+				if err := patchAttribute(element, sub, op, e); err != nil {
+					return err
+				}
+			}
+			patched = append(patched, element)
+		case scim.PatchOperationReplace:
+			singular := attr
+			singular.MultiValued = false
+			v, err := singular.validate(value)
+			if err != nil {
+				return err
+			}
+			patched = append(patched, v)
+		}
+	}
+
+	if !matched {
+		return errors.ScimErrorNoTarget
+	}
+	setOrRemove(object, attr.Name, patched)
+	return nil
+}
+
+// mutable checks whether the operation is allowed based on the mutability of the attribute and its current value.
+func mutable(attr attribute, op string, current interface{}) error {
+	switch attr.Mutability {
+	case "readOnly":
+		return errors.ScimErrorMutability
+	case "immutable":
+		if op != scim.PatchOperationAdd || current != nil {
+			return errors.ScimErrorMutability
+		}
+	}
+	return nil
+}
+
+// validate checks whether the value is compatible with the attribute. Singular values of multi valued attributes are
+// converted to a list of values.
+func (a attribute) validate(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, errors.ScimErrorInvalidValue
+	}
+
+	if a.MultiValued {
+		var vs []interface{}
+		if list, ok := value.([]interface{}); ok {
+			vs = list
+		} else {
+			vs = []interface{}{value}
+		}
+
+		singular := a
+		singular.MultiValued = false
+		validated := make([]interface{}, 0, len(vs))
+		for _, v := range vs {
+			v, err := singular.validate(v)
+			if err != nil {
+				return nil, err
+			}
+			validated = append(validated, v)
+		}
+		return validated, nil
+	}
+
+	switch a.Type {
+	case "":
+		// unknown (sub) attributes are not validated.
+		return clone(value), nil
+	case "complex":
+		// identity providers (e.g. Azure AD) assign a complex value by its value sub attribute, e.g. the id of a manager.
+		if v, ok := value.(string); ok {
+			if _, ok := a.subAttribute("value"); ok {
+				value = map[string]interface{}{"value": v}
+			}
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.ScimErrorInvalidValue
+		}
+		validated := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			sub, ok := a.subAttribute(k)
+			if !ok {
+				validated[k] = clone(v)
+				continue
+			}
+			// read only sub attributes are ignored.
+			if sub.Mutability == "readOnly" {
+				continue
+			}
+			v, err := sub.validate(v)
+			if err != nil {
+				return nil, err
+			}
+			validated[sub.Name] = v
+		}
+		return validated, nil
+	case "boolean":
+		// identity providers (e.g. Azure AD) send booleans as strings, "True" or "False".
+		if v, ok := value.(string); ok {
+			b, err := strconv.ParseBool(strings.ToLower(v))
+			if err != nil {
+				return nil, errors.ScimErrorInvalidValue
+			}
+			return b, nil
+		}
+		if _, ok := value.(bool); !ok {
+			return nil, errors.ScimErrorInvalidValue
+		}
+	case "integer", "decimal":
+		switch value.(type) {
+		case float64, json.Number, int:
+		default:
+			return nil, errors.ScimErrorInvalidValue
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return nil, errors.ScimErrorInvalidValue
+		}
+	}
+	return value, nil
+}
+
+func containsValue(vs []interface{}, v interface{}) bool {
+	for _, e := range vs {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// set sets the value of the given key, replacing keys that only differ in case.
+func set(m map[string]interface{}, key string, value interface{}) {
+	remove(m, key)
+	m[key] = value
+}
+
+// remove removes the given key (case insensitive).
+func remove(m map[string]interface{}, key string) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			delete(m, k)
+		}
+	}
+}
+
+// setOrRemove sets the value of the given key, or removes it if the (complex or multi valued) value is empty, so it
+// is considered unassigned.
+func setOrRemove(m map[string]interface{}, key string, value interface{}) {
+	if reflect.ValueOf(value).Len() == 0 {
+		remove(m, key)
+		return
+	}
+	set(m, key, value)
+}
@@ -0,0 +1,240 @@
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/elimity-com/scim"
+	"github.com/elimity-com/scim/errors"
+	"github.com/elimity-com/scim/schema"
+)
+
+const enterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+func TestParsePath(t *testing.T) {
+	s := newResourceSchema(schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+	for _, test := range []struct {
+		path                         string
+		uri, attribute, subAttribute string
+		filter                       bool
+	}{
+		{path: "userName", attribute: "userName"},
+		{path: "name.familyName", attribute: "name", subAttribute: "familyName"},
+		{path: "emails[type eq \"work\"]", attribute: "emails", filter: true},
+		{path: "emails[type eq \"work\"].value", attribute: "emails", subAttribute: "value", filter: true},
+		{path: "members[value eq \"0001\"].$ref", attribute: "members", subAttribute: "$ref", filter: true},
+		{path: "urn:ietf:params:scim:schemas:core:2.0:User:userName", uri: "urn:ietf:params:scim:schemas:core:2.0:User", attribute: "userName"},
+		{path: enterpriseUser, uri: enterpriseUser},
+		{path: enterpriseUser + ":manager.value", uri: enterpriseUser, attribute: "manager", subAttribute: "value"},
+		// schema URIs are case insensitive.
+		{path: "URN:IETF:PARAMS:SCIM:SCHEMAS:EXTENSION:ENTERPRISE:2.0:USER:department", uri: enterpriseUser, attribute: "department"},
+	} {
+		path, err := s.parsePath(test.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.path, err)
+			continue
+		}
+		if path.uri != test.uri || path.attribute != test.attribute || path.subAttribute != test.subAttribute || (path.filter != nil) != test.filter {
+			t.Errorf("%s: unexpected path: %+v", test.path, path)
+		}
+	}
+
+	for _, p := range []string{
+		"",
+		"1userName",
+		"user name",
+		"name.",
+		"name.family name",
+		"emails[type eq \"work\"",
+		"emails]type eq \"work\"[",
+		"emails[type eq \"work\"].",
+		"emails[type eq \"work\"]value",
+		"urn:ietf:params:scim:schemas:extension:unknown:2.0:User:userName",
+		enterpriseUser + "department",
+	} {
+		if _, err := s.parsePath(p); err != errors.ScimErrorInvalidPath {
+			t.Errorf("%q: expected invalidPath, got %v", p, err)
+		}
+	}
+}
+
+// patchUser is the user that gets patched in TestPatch.
+const patchUser = `{
+	"id": "0001",
+	"userName": "bjensen",
+	"name": {"givenName": "Barbara"},
+	"emails": [
+		{"value": "bjensen@example.com", "type": "work"},
+		{"value": "babs@jensen.org", "type": "home"}
+	]
+}`
+
+func decode(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPatch(t *testing.T) {
+	s := newResourceSchema(schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+	for _, test := range []struct {
+		name     string
+		op, path string
+		value    interface{}
+		// expected holds the attributes of the patched user that differ from patchUser, null if they are removed.
+		expected string
+	}{
+		// add
+		{"add attribute", "add", "displayName", "Babs", `{"displayName": "Babs"}`},
+		{"add without path", "add", "", map[string]interface{}{"displayName": "Babs", "nickName": "B"}, `{"displayName": "Babs", "nickName": "B"}`},
+		{"add sub attribute", "add", "name.familyName", "Jensen", `{"name": {"givenName": "Barbara", "familyName": "Jensen"}}`},
+		{"add complex merges", "add", "name", map[string]interface{}{"familyName": "Jensen"}, `{"name": {"givenName": "Barbara", "familyName": "Jensen"}}`},
+		{"add value", "add", "emails", map[string]interface{}{"value": "b@example.com"}, `{"emails": [
+			{"value": "bjensen@example.com", "type": "work"},
+			{"value": "babs@jensen.org", "type": "home"},
+			{"value": "b@example.com"}
+		]}`},
+		{"add existing value", "add", "emails", []interface{}{map[string]interface{}{"value": "babs@jensen.org", "type": "home"}}, `{}`},
+		{"add to filtered values", "add", `emails[type eq "work"]`, map[string]interface{}{"primary": true}, `{"emails": [
+			{"value": "bjensen@example.com", "type": "work", "primary": true},
+			{"value": "babs@jensen.org", "type": "home"}
+		]}`},
+		{"add to extension", "add", enterpriseUser + ":department", "Sales", `{"` + enterpriseUser + `": {"department": "Sales"}}`},
+		{"add extension", "add", enterpriseUser, map[string]interface{}{"employeeNumber": "1"}, `{"` + enterpriseUser + `": {"employeeNumber": "1"}}`},
+		{"add boolean string", "add", "active", "True", `{"active": true}`},
+		{"add case insensitive", "add", "USERNAME", "babs", `{"userName": "babs"}`},
+		// replace
+		{"replace attribute", "replace", "userName", "babs", `{"userName": "babs"}`},
+		{"replace without path", "replace", "", map[string]interface{}{"userName": "babs", "id": "0001"}, `{"userName": "babs"}`},
+		{"replace values", "replace", "emails", map[string]interface{}{"value": "b@example.com"}, `{"emails": [{"value": "b@example.com"}]}`},
+		{"replace filtered value", "replace", `emails[type eq "home"]`, map[string]interface{}{"value": "b@example.com", "type": "other"}, `{"emails": [
+			{"value": "bjensen@example.com", "type": "work"},
+			{"value": "b@example.com", "type": "other"}
+		]}`},
+		{"replace filtered sub attribute", "replace", `emails[type eq "work"].value`, "b@example.com", `{"emails": [
+			{"value": "b@example.com", "type": "work"},
+			{"value": "babs@jensen.org", "type": "home"}
+		]}`},
+		{"replace sub attribute of every value", "replace", "emails.type", "other", `{"emails": [
+			{"value": "bjensen@example.com", "type": "other"},
+			{"value": "babs@jensen.org", "type": "other"}
+		]}`},
+		{"replace manager by id", "replace", enterpriseUser + ":manager", "0002", `{"` + enterpriseUser + `": {"manager": {"value": "0002"}}}`},
+		// remove
+		{"remove attribute", "remove", "userName", nil, `{"userName": null}`},
+		{"remove sub attribute", "remove", "name.givenName", nil, `{"name": null}`},
+		{"remove filtered value", "remove", `emails[type eq "work"]`, nil, `{"emails": [{"value": "babs@jensen.org", "type": "home"}]}`},
+		{"remove all filtered values", "remove", `emails[value co "@"]`, nil, `{"emails": null}`},
+		{"remove filtered sub attribute", "remove", `emails[type eq "work"].type`, nil, `{"emails": [
+			{"value": "bjensen@example.com"},
+			{"value": "babs@jensen.org", "type": "home"}
+		]}`},
+		{"remove missing attribute", "remove", "nickName", nil, `{}`},
+		{"remove missing extension", "remove", enterpriseUser + ":department", nil, `{}`},
+		{"remove extension", "remove", enterpriseUser, nil, `{}`},
+		// operations are case insensitive.
+		{"capitalised operation", "Replace", "userName", "babs", `{"userName": "babs"}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resource := decode(t, patchUser)
+			if err := s.patch(resource, withoutID("0001", []scim.PatchOperation{{Op: test.op, Path: test.path, Value: test.value}})); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := decode(t, patchUser)
+			for k, v := range decode(t, test.expected) {
+				if v == nil {
+					delete(expected, k)
+					continue
+				}
+				expected[k] = v
+			}
+			if !reflect.DeepEqual(expected, normalise(t, resource)) {
+				t.Errorf("expected %v, got %v", expected, resource)
+			}
+		})
+	}
+}
+
+func TestInvalidPatch(t *testing.T) {
+	s := newResourceSchema(schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+	for _, test := range []struct {
+		name     string
+		op, path string
+		value    interface{}
+		err      error
+	}{
+		{"unknown operation", "move", "userName", "babs", errors.ScimErrorInvalidSyntax},
+		{"remove without path", "remove", "", nil, errors.ScimErrorNoTarget},
+		{"add without path or object", "add", "", "babs", errors.ScimErrorInvalidValue},
+		{"unknown attribute", "add", "unknown", "x", errors.ScimErrorInvalidPath},
+		{"unknown sub attribute", "add", "name.unknown", "x", errors.ScimErrorInvalidPath},
+		{"sub attribute of a simple attribute", "add", "userName.value", "x", errors.ScimErrorInvalidPath},
+		{"filter on a simple attribute", "add", `userName[value eq "x"]`, "x", errors.ScimErrorInvalidPath},
+		{"filter on a singular complex attribute", "add", `name[givenName eq "Barbara"]`, "x", errors.ScimErrorInvalidPath},
+		{"unknown filtered sub attribute", "replace", `emails[type eq "work"].unknown`, "x", errors.ScimErrorInvalidPath},
+		{"invalid filter", "replace", `emails[urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"]`, "x", errors.ScimErrorInvalidPath},
+		{"core schema as a whole", "replace", "urn:ietf:params:scim:schemas:core:2.0:User", map[string]interface{}{}, errors.ScimErrorInvalidPath},
+		{"no filtered value", "replace", `emails[type eq "other"]`, map[string]interface{}{"value": "x"}, errors.ScimErrorNoTarget},
+		{"no value to remove", "remove", `emails[type eq "other"]`, nil, errors.ScimErrorNoTarget},
+		{"sub attribute without values", "replace", "phoneNumbers.type", "work", errors.ScimErrorNoTarget},
+		{"read only attribute", "replace", "id", "0002", errors.ScimErrorMutability},
+		{"read only sub attribute", "replace", "meta.created", "2020-08-01T12:00:00Z", errors.ScimErrorMutability},
+		{"remove read only attribute", "remove", "groups", nil, errors.ScimErrorMutability},
+		{"read only attribute without path", "replace", "", map[string]interface{}{"id": "0002"}, errors.ScimErrorMutability},
+		{"invalid string", "replace", "userName", 1.0, errors.ScimErrorInvalidValue},
+		{"invalid boolean", "replace", "active", "yes", errors.ScimErrorInvalidValue},
+		{"invalid complex", "replace", "name", "Jensen", errors.ScimErrorInvalidValue},
+		{"invalid sub attribute", "replace", "name", map[string]interface{}{"givenName": true}, errors.ScimErrorInvalidValue},
+		{"invalid value of values", "add", "emails", []interface{}{1.0}, errors.ScimErrorInvalidValue},
+		{"null value", "add", "userName", nil, errors.ScimErrorInvalidValue},
+		{"extension without object", "add", enterpriseUser, "x", errors.ScimErrorInvalidValue},
+		{"add non object to filtered values", "add", `emails[type eq "work"]`, "x", errors.ScimErrorInvalidValue},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resource := decode(t, patchUser)
+			err := s.patch(resource, withoutID("0001", []scim.PatchOperation{{Op: test.op, Path: test.path, Value: test.value}}))
+			if err != test.err {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestMutable(t *testing.T) {
+	for _, test := range []struct {
+		mutability string
+		op         string
+		current    interface{}
+		allowed    bool
+	}{
+		{"readWrite", "replace", "x", true},
+		{"writeOnly", "remove", "x", true},
+		{"readOnly", "add", nil, false},
+		{"readOnly", "remove", nil, false},
+		// immutable attributes can only be added if they have no value yet.
+		{"immutable", "add", nil, true},
+		{"immutable", "add", "x", false},
+		{"immutable", "replace", nil, false},
+		{"immutable", "remove", "x", false},
+	} {
+		err := mutable(attribute{Name: "attr", Mutability: test.mutability}, test.op, test.current)
+		if (err == nil) != test.allowed {
+			t.Errorf("%s %s (%v): expected allowed %v, got %v", test.op, test.mutability, test.current, test.allowed, err)
+		}
+	}
+}
+
+// normalise converts the patched resource to its JSON representation, so it can be compared to decoded JSON.
+func normalise(t *testing.T, resource map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decode(t, string(raw))
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/elimity-com/scim"
+)
+
+// snapshot is the representation of the resources of a handler on disk.
+type snapshot struct {
+	LastID    int                        `json:"lastId"`
+	Revision  int                        `json:"revision"`
+	IDs       []string                   `json:"ids"`
+	Resources map[string]snapshotElement `json:"resources"`
+}
+
+type snapshotElement struct {
+	Attributes   scim.ResourceAttributes `json:"attributes"`
+	Created      time.Time               `json:"created"`
+	LastModified time.Time               `json:"lastModified"`
+	Version      int                     `json:"version"`
+}
+
+// PersistentServer returns the reference server with its resources persisted in the given directory, one file per
+// resource type. The generated test data is only used if nothing was persisted yet.
+func PersistentServer(dir string) (ReferenceServer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ReferenceServer{}, err
+	}
+
+	s := Server()
+	for _, resourceType := range s.ResourceTypes {
+		h, ok := resourceType.Handler.(*ResourceHandler)
+		if !ok {
+			continue
+		}
+		h.file = filepath.Join(dir, resourceType.Name+".json")
+		if err := h.load(); err != nil {
+			return ReferenceServer{}, err
+		}
+	}
+	return s, nil
+}
+
+// reset handles POST requests to the "/Reset" endpoint, it replaces the resources of all resource types with the
+// generated test data. This endpoint is not part of SCIM, it is only served if the Reset feature is enabled.
+func (s ReferenceServer) reset(w http.ResponseWriter) {
+	var handlers []*ResourceHandler
+	for _, resourceType := range s.ResourceTypes {
+		if h, ok := resourceType.Handler.(*ResourceHandler); ok {
+			handlers = append(handlers, h)
+		}
+	}
+	if len(handlers) != 0 {
+		// all handlers share the same directory, the identifiers start over.
+		dir := handlers[0].directory
+		dir.mu.Lock()
+		defer dir.mu.Unlock()
+
+		dir.lastID = 0
+		for _, h := range handlers {
+			h.seed()
+			h.save()
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// load loads the resources from the file of the handler, the generated test data is persisted if the file does not
+// exist yet.
+func (h *ResourceHandler) load() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return h.write()
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var snap snapshot
+	d := json.NewDecoder(f)
+	d.UseNumber()
+	if err := d.Decode(&snap); err != nil {
+		return err
+	}
+	h.data = make(map[string]Data, len(snap.Resources))
+	h.ids = snap.IDs
+	if snap.LastID > h.lastID {
+		h.lastID = snap.LastID
+	}
+	h.revision = snap.Revision
+	for id, element := range snap.Resources {
+		h.data[id] = Data{
+			resourceAttributes: element.Attributes,
+			meta: meta{
+				created:      element.Created,
+				lastModified: element.LastModified,
+				version:      element.Version,
+			},
+		}
+	}
+	return nil
+}
+
+// save persists the resources if the handler has a file, failures are logged. The caller must hold the lock.
+func (h *ResourceHandler) save() {
+	if h.file == "" {
+		return
+	}
+	if err := h.write(); err != nil {
+		log.Printf("failed persisting resources: %v", err)
+	}
+}
+
+// write writes the resources to the file of the handler, the caller must hold the lock. The file is replaced at
+// once, so it never contains a partial write.
+func (h *ResourceHandler) write() error {
+	snap := snapshot{
+		LastID:    h.lastID,
+		Revision:  h.revision,
+		IDs:       h.ids,
+		Resources: make(map[string]snapshotElement, len(h.data)),
+	}
+	for id, data := range h.data {
+		snap.Resources[id] = snapshotElement{
+			Attributes:   data.resourceAttributes,
+			Created:      data.meta.created,
+			LastModified: data.meta.lastModified,
+			Version:      data.meta.version,
+		}
+	}
+	raw, err := json.MarshalIndent(snap, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(h.file), filepath.Base(h.file)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), h.file)
+}
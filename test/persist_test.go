@@ -0,0 +1,96 @@
+package test_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+// TestPersistentServer checks that the resources survive a restart of the server and that they get replaced by the
+// generated test data on a reset.
+func TestPersistentServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scim-reference-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := test.PersistentServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s)
+	resp, err := http.Post(server.URL+"/Users", "application/scim+json", strings.NewReader(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "persisted"
+	}`))
+	server.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// restart the server on the same directory.
+	s, err = test.PersistentServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = httptest.NewServer(s)
+	defer server.Close()
+
+	// the reset endpoint is only served if it is enabled.
+	resp, err = http.Post(server.URL+"/Reset", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the reset endpoint to be disabled, got %d", resp.StatusCode)
+	}
+	s.Features.Reset = true
+
+	if n := totalResults(t, server.URL+`/Users?filter=userName+eq+"persisted"`); n != 1 {
+		t.Errorf("expected the user to survive a restart, got %d results", n)
+	}
+
+	resp, err = http.Post(server.URL+"/Reset", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if n := totalResults(t, server.URL+`/Users?filter=userName+eq+"persisted"`); n != 0 {
+		t.Errorf("expected the user to be removed on a reset, got %d results", n)
+	}
+	if n := totalResults(t, server.URL+"/Users"); n != 20 {
+		t.Errorf("expected the generated test data after a reset, got %d results", n)
+	}
+}
+
+// totalResults returns the total results of the list response of the given url.
+func totalResults(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		TotalResults int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	return list.TotalResults
+}
@@ -0,0 +1,26 @@
+package test_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/postman"
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/stretchr/testify/suite"
+)
+
+// TestPostman runs an excerpt of the SCIM validator collection of Azure AD, with its wrong paths, against the reference
+// server.
+func TestPostman(t *testing.T) {
+	collection, err := postman.LoadFile("testdata/postman-azure.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	s := postman.NewSuite(collection)
+	s.BaseURL(server.URL)
+	suite.Run(t, s)
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc6585#section-4
+
+// rateLimiter counts the requests per second, in fixed windows.
+type rateLimiter struct {
+	mu     sync.Mutex
+	window time.Time
+	count  int
+}
+
+// allow returns whether another request is allowed within the given limit per second, and the delay until the next
+// window otherwise.
+func (l *rateLimiter) allow(limit int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if window := now.Truncate(time.Second); !window.Equal(l.window) {
+		l.window, l.count = window, 0
+	}
+	if l.count >= limit {
+		return false, l.window.Add(time.Second).Sub(now)
+	}
+	l.count++
+	return true, 0
+}
+
+// tooManyRequests writes a 429 (Too Many Requests) error with the number of seconds to wait in the Retry-After header.
+func tooManyRequests(w http.ResponseWriter, delay time.Duration) {
+	seconds := int((delay + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, errors.ScimError{
+		Detail: "Too many requests, retry after the delay of the Retry-After header.",
+		Status: http.StatusTooManyRequests,
+	})
+}
@@ -0,0 +1,91 @@
+package test_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	scim "github.com/di-wu/scim-test-suite"
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/di-wu/scim-test-suite/util"
+	"github.com/stretchr/testify/suite"
+)
+
+// TestRateLimit runs the rate limit tests against the reference server with a rate limit, so they are not skipped.
+func TestRateLimit(t *testing.T) {
+	limited := test.Server()
+	limited.Features.RateLimit = 20
+	server := httptest.NewServer(limited)
+	defer server.Close()
+
+	var finished bool
+	t.Run("TestRateLimit", func(t *testing.T) {
+		s := new(scim.SCIMTestSuite)
+		s.SetT(t)
+		s.BaseURL(server.URL)
+		s.Strict(true)
+		s.TestRateLimit()
+		finished = true
+	})
+	if !finished {
+		t.Error("the rate limit tests were skipped")
+	}
+}
+
+type retrySuite struct {
+	util.Suite
+}
+
+func (s *retrySuite) TestRetryThrottled() {
+	resp, _ := s.Burst("/Users?count=1", 100)
+	s.Require().NotNil(resp, "not rate limited")
+	_ = resp.Body.Close()
+	s.Equal(http.StatusOK, s.Get("/Users?count=1").StatusCode)
+}
+
+// TestRetryThrottled checks that the suites retry throttled requests once enabled.
+func TestRetryThrottled(t *testing.T) {
+	limited := test.Server()
+	limited.Features.RateLimit = 20
+	server := httptest.NewServer(limited)
+	defer server.Close()
+
+	s := new(retrySuite)
+	s.BaseURL(server.URL)
+	s.RetryThrottled(3)
+	suite.Run(t, s)
+}
+
+// TestRateLimitNestedRequests checks that the operations of a bulk request and searches count as a single request.
+func TestRateLimitNestedRequests(t *testing.T) {
+	for _, req := range []struct{ path, body string }{
+		{"/Users/.search", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],
+			"attributes": ["userName"],
+			"count": 1
+		}`},
+		{"/Bulk", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],
+			"Operations": [
+				{"method": "POST", "path": "/Users", "bulkId": "a", "data": {"userName": "nested-a"}},
+				{"method": "POST", "path": "/Users", "bulkId": "b", "data": {"userName": "nested-b"}},
+				{"method": "DELETE", "path": "/Users/bulkId:a"}
+			]
+		}`},
+	} {
+		// every request gets its own server, so it has the whole limit.
+		limited := test.Server()
+		limited.Features.Bulk = true
+		limited.Features.Search = true
+		limited.Features.RateLimit = 1
+		server := httptest.NewServer(limited)
+
+		response := do(t, http.MethodPost, server.URL+req.path, http.StatusOK, req.body)
+		for _, op := range bulkOperations(response) {
+			if op["status"] == "429" {
+				t.Errorf("%s: unexpected rate limited operation: %v", req.path, op)
+			}
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,210 @@
+package test_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/report"
+)
+
+// reportOutput is the output of "go test -json" of two suites with a test of the same name.
+const reportOutput = `build output is ignored
+{"Action":"run","Package":"example","Test":"TestSCIM"}
+{"Action":"run","Package":"example","Test":"TestSCIM/TestCreateUser"}
+{"Action":"run","Package":"example","Test":"TestSCIM/TestCreateUser/Created"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Output":"=== RUN   TestSCIM/TestCreateUser/Created\n"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Output":"    users.go:25: \n"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Output":"        \tError:      \tNot equal: \n"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Output":"        \t            \texpected: 201\n"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Output":"    --- FAIL: TestSCIM/TestCreateUser/Created (0.01s)\n"}
+{"Action":"fail","Package":"example","Test":"TestSCIM/TestCreateUser/Created","Elapsed":0.01}
+{"Action":"run","Package":"example","Test":"TestSCIM/TestCreateUser/Location"}
+{"Action":"output","Package":"example","Test":"TestSCIM/TestCreateUser/Location","Output":"    severity.go:18: WARNING (SHOULD): missing header\n"}
+{"Action":"pass","Package":"example","Test":"TestSCIM/TestCreateUser/Location","Elapsed":0}
+{"Action":"fail","Package":"example","Test":"TestSCIM/TestCreateUser","Elapsed":0.01}
+{"Action":"fail","Package":"example","Test":"TestSCIM","Elapsed":0.02}
+{"Action":"run","Package":"example","Test":"TestIdP"}
+{"Action":"run","Package":"example","Test":"TestIdP/TestCreateUser"}
+{"Action":"pass","Package":"example","Test":"TestIdP/TestCreateUser","Elapsed":0}
+{"Action":"run","Package":"example","Test":"TestIdP/TestGetGroups"}
+{"Action":"output","Package":"example","Test":"TestIdP/TestGetGroups","Output":"    okta.go:40: not supported\n"}
+{"Action":"skip","Package":"example","Test":"TestIdP/TestGetGroups","Elapsed":0}
+{"Action":"pass","Package":"example","Test":"TestIdP","Elapsed":0}
+`
+
+var (
+	scimRefs = report.References{
+		"TestCreateUser/Created":  {Spec: "RFC7644", Section: "3.3", Level: report.MUST},
+		"TestCreateUser/Location": {Spec: "RFC7644", Section: "3.3.1", Level: report.SHOULD},
+	}
+	oktaRefs = report.References{
+		"TestCreateUser": {Spec: "Okta", Section: "Create Okta user"},
+		"TestGetGroups":  {Spec: "Okta", Section: "Get groups"},
+	}
+)
+
+func parseReport(t *testing.T) report.Report {
+	t.Helper()
+	refs, err := report.Merge(scimRefs.Scope("TestSCIM"), oktaRefs.Scope("TestIdP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := report.Parse(strings.NewReader(reportOutput), refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMergeReferences(t *testing.T) {
+	if _, err := report.Merge(scimRefs, oktaRefs); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := report.Merge(scimRefs, report.References{"TestCreateUser/Created": {}}); err == nil {
+		t.Error("expected an error for a duplicate test name")
+	}
+	if _, err := report.Merge(scimRefs.Scope("TestSCIM"), scimRefs.Scope("TestOther")); err != nil {
+		t.Errorf("unexpected error for scoped references: %v", err)
+	}
+}
+
+func TestLookupReference(t *testing.T) {
+	unscoped := report.References{
+		"TestGetGroups":      {Section: "groups"},
+		"TestUsers/Get user": {Section: "get user"},
+	}.Index()
+	scoped := oktaRefs.Scope("TestIdP").Index()
+
+	for _, test := range []struct {
+		index   report.Index
+		name    string
+		section string // empty if no reference is expected
+	}{
+		// the top level test is ignored for unscoped references.
+		{unscoped, "TestIdP/TestGetGroups", "groups"},
+		{unscoped, "TestAnything/TestGetGroups/StatusCode", "groups"},
+		// spaces are underscores and duplicate subtests get a suffix.
+		{unscoped, "TestIdP/TestUsers/Get_user#01/Status", "get user"},
+		{unscoped, "TestIdP/TestUsers", ""},
+		{scoped, "TestIdP/TestCreateUser/StatusCode", "Create Okta user"},
+		{scoped, "TestSCIM/TestCreateUser/Created", ""},
+		{scoped, "TestCreateUser", ""},
+	} {
+		ref, ok := test.index.Lookup(test.name)
+		if ok != (test.section != "") || ref.Section != test.section {
+			t.Errorf("%s: expected %q, got %q (%v)", test.name, test.section, ref.Section, ok)
+		}
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	r := parseReport(t)
+	tests := make(map[string]report.Test)
+	for _, test := range r.Tests {
+		tests[test.Name] = test
+	}
+	if len(tests) != 7 {
+		t.Fatalf("expected 7 tests, got %d", len(tests))
+	}
+
+	created := tests["TestSCIM/TestCreateUser/Created"]
+	if created.Status != report.StatusFail || created.Reference == nil || created.Reference.Section != "3.3" {
+		t.Errorf("unexpected test: %+v", created)
+	}
+	if len(created.Messages) != 1 || !strings.Contains(created.Messages[0], "Not equal") {
+		t.Errorf("unexpected messages: %q", created.Messages)
+	}
+
+	location := tests["TestSCIM/TestCreateUser/Location"]
+	if location.Status != report.StatusPass || len(location.Warnings) != 1 || len(location.Messages) != 0 {
+		t.Errorf("unexpected test: %+v", location)
+	}
+
+	// the tests of the suites do not get each others references.
+	if ref := tests["TestSCIM/TestCreateUser"].Reference; ref != nil {
+		t.Errorf("unexpected reference: %+v", ref)
+	}
+	if ref := tests["TestIdP/TestCreateUser"].Reference; ref == nil || ref.Spec != "Okta" {
+		t.Errorf("unexpected reference: %+v", ref)
+	}
+
+	skipped := tests["TestIdP/TestGetGroups"]
+	if skipped.Status != report.StatusSkip || len(skipped.Messages) != 1 {
+		t.Errorf("unexpected test: %+v", skipped)
+	}
+
+	if _, err := report.Parse(strings.NewReader("{invalid\n"), nil); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	statuses := make(map[string]report.Status)
+	for _, requirement := range parseReport(t).Matrix() {
+		statuses[requirement.Spec+" "+requirement.Section] = requirement.Status
+	}
+	for requirement, status := range map[string]report.Status{
+		"RFC7644 3.3":           report.StatusFail,
+		"RFC7644 3.3.1":         report.StatusWarn,
+		"Okta Create Okta user": report.StatusPass,
+		"Okta Get groups":       report.StatusSkip,
+	} {
+		if statuses[requirement] != status {
+			t.Errorf("%s: expected %s, got %s", requirement, status, statuses[requirement])
+		}
+	}
+	if len(statuses) != 4 {
+		t.Errorf("unexpected requirements: %v", statuses)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := parseReport(t).WriteJUnit(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites struct {
+		Suites []struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Skipped  int    `xml:"skipped,attr"`
+			Cases    []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+				SystemOut string `xml:"system-out"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 2 {
+		t.Fatalf("expected 2 test suites, got %d", len(suites.Suites))
+	}
+
+	scim, idp := suites.Suites[0], suites.Suites[1]
+	if scim.Name != "example/TestSCIM" || scim.Tests != 3 || scim.Failures != 2 || scim.Skipped != 0 {
+		t.Errorf("unexpected test suite: %+v", scim)
+	}
+	if idp.Name != "example/TestIdP" || idp.Tests != 2 || idp.Failures != 0 || idp.Skipped != 1 {
+		t.Errorf("unexpected test suite: %+v", idp)
+	}
+	for _, c := range scim.Cases {
+		switch c.Name {
+		case "TestCreateUser/Created":
+			if c.Failure == nil || c.Failure.Message != "Not equal:" {
+				t.Errorf("unexpected failure: %+v", c.Failure)
+			}
+		case "TestCreateUser/Location":
+			if c.Failure != nil || !strings.Contains(c.SystemOut, "WARNING") {
+				t.Errorf("unexpected test case: %+v", c)
+			}
+		}
+	}
+}
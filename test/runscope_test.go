@@ -0,0 +1,25 @@
+package test_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/runscope"
+	"github.com/di-wu/scim-test-suite/test"
+	"github.com/stretchr/testify/suite"
+)
+
+// TestRunscope runs an excerpt of the Okta spec test, as a Runscope export, against the reference server.
+func TestRunscope(t *testing.T) {
+	spec, err := runscope.LoadFile("testdata/runscope-okta.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	s := runscope.NewSuite(spec)
+	s.BaseURL(server.URL)
+	suite.Run(t, s)
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/elimity-com/scim/schema"
+)
+
+// attribute contains the characteristics of an attribute that are needed to evaluate filters and patches.
+type attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	SubAttributes []attribute `json:"subAttributes"`
+}
+
+// subAttribute returns the sub attribute with the given name (case insensitive).
+func (a attribute) subAttribute(name string) (attribute, bool) {
+	return find(a.SubAttributes, name)
+}
+
+func find(attributes []attribute, name string) (attribute, bool) {
+	for _, attr := range attributes {
+		if strings.EqualFold(attr.Name, name) {
+			return attr, true
+		}
+	}
+	return attribute{}, false
+}
+
+// commonAttributes are the attributes that are part of every resource.
+// RFC: https://tools.ietf.org/html/rfc7643#section-3.1
+var commonAttributes = []attribute{
+	{Name: "id", Type: "string", CaseExact: true, Mutability: "readOnly"},
+	{Name: "externalId", Type: "string", CaseExact: true, Mutability: "readWrite"},
+	{Name: "meta", Type: "complex", Mutability: "readOnly", SubAttributes: []attribute{
+		{Name: "resourceType", Type: "string", CaseExact: true, Mutability: "readOnly"},
+		{Name: "created", Type: "dateTime", Mutability: "readOnly"},
+		{Name: "lastModified", Type: "dateTime", Mutability: "readOnly"},
+		{Name: "location", Type: "reference", CaseExact: true, Mutability: "readOnly"},
+		{Name: "version", Type: "string", CaseExact: true, Mutability: "readOnly"},
+	}},
+}
+
+// resourceSchema is the schema of a resource type, including its extensions.
+type resourceSchema struct {
+	id         string
+	attributes []attribute
+	extensions map[string][]attribute
+}
+
+func newResourceSchema(s schema.Schema, extensions ...schema.Schema) resourceSchema {
+	rs := resourceSchema{
+		id:         s.ID,
+		attributes: append(parseAttributes(s), commonAttributes...),
+		extensions: make(map[string][]attribute),
+	}
+	for _, extension := range extensions {
+		rs.extensions[extension.ID] = parseAttributes(extension)
+	}
+	return rs
+}
+
+func parseAttributes(s schema.Schema) []attribute {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	var parsed struct {
+		Attributes []attribute `json:"attributes"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		panic(err)
+	}
+	return parsed.Attributes
+}
+
+// scope returns the attributes and the (sub)map of the resource that belong to the given schema URI. An empty URI
+// refers to the core schema.
+func (s resourceSchema) scope(uri string, resource map[string]interface{}) ([]attribute, map[string]interface{}, bool) {
+	if uri == "" || strings.EqualFold(uri, s.id) {
+		return s.attributes, resource, true
+	}
+	for id, attributes := range s.extensions {
+		if strings.EqualFold(uri, id) {
+			extension, _ := get(resource, id).(map[string]interface{})
+			return attributes, extension, true
+		}
+	}
+	return nil, nil, false
+}
+
+// get returns the value of the given key (case insensitive).
+func get(m map[string]interface{}, key string) interface{} {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
@@ -2,20 +2,28 @@ package test
 
 import (
 	"fmt"
-	"math/rand"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/di-wu/scim-filter-parser"
-
 	"github.com/elimity-com/scim"
 	"github.com/elimity-com/scim/errors"
 	"github.com/elimity-com/scim/optional"
 	"github.com/elimity-com/scim/schema"
 )
 
-func Server() scim.Server {
-	return scim.Server{
+// Server returns the reference server with all optional features disabled, they can be enabled by its Features.
+func Server() ReferenceServer {
+	var (
+		features = new(Features)
+		dir      = new(directory)
+		users    = newTestResourceHandler(dir, features, schema.CoreUserSchema(), schema.ExtensionEnterpriseUser())
+		groups   = newTestResourceHandler(dir, features, schema.CoreGroupSchema())
+	)
+	dir.users, dir.groups = users, groups
+	return ReferenceServer{Features: features, limiter: new(rateLimiter), Server: scim.Server{
 		Config: scim.ServiceProviderConfig{
 			SupportFiltering: true,
 			SupportPatch:     true,
@@ -27,7 +35,11 @@ func Server() scim.Server {
 				Endpoint:    "/Users",
 				Description: optional.NewString("User Account"),
 				Schema:      schema.CoreUserSchema(),
-				Handler:     newTestResourceHandler(),
+				// identity providers (e.g. Azure AD) send the enterprise extension to the users endpoint.
+				SchemaExtensions: []scim.SchemaExtension{
+					{Schema: schema.ExtensionEnterpriseUser()},
+				},
+				Handler: users,
 			},
 			{
 				ID:          optional.NewString("EnterpriseUser"),
@@ -38,7 +50,7 @@ func Server() scim.Server {
 				SchemaExtensions: []scim.SchemaExtension{
 					{Schema: schema.ExtensionEnterpriseUser()},
 				},
-				Handler: newTestResourceHandler(),
+				Handler: newTestResourceHandler(dir, features, schema.CoreUserSchema(), schema.ExtensionEnterpriseUser()),
 			},
 			{
 				ID:          optional.NewString("Group"),
@@ -46,240 +58,375 @@ func Server() scim.Server {
 				Endpoint:    "/Groups",
 				Description: optional.NewString("Group"),
 				Schema:      schema.CoreGroupSchema(),
-				Handler:     newTestResourceHandler(),
+				Handler:     groups,
 			},
 		},
+	}}
+}
+
+func newTestResourceHandler(dir *directory, features *Features, s schema.Schema, extensions ...schema.Schema) *ResourceHandler {
+	h := &ResourceHandler{
+		directory: dir,
+		schema:    newResourceSchema(s, extensions...),
+		features:  features,
 	}
+	h.seed()
+	return h
 }
 
-func newTestResourceHandler() scim.ResourceHandler {
-	data := make(map[string]Data)
+// seed replaces all resources with the generated test data, the caller must hold the lock if the handler is in use.
+func (h *ResourceHandler) seed() {
+	h.data = make(map[string]Data)
+	h.ids = nil
+	h.revision = 0
 
 	// Generate enough test data to test pagination
 	for i := 1; i < 21; i++ {
-		data[fmt.Sprintf("%04d", i)] = Data{
-			resourceAttributes: scim.ResourceAttributes{
-				"userName":   fmt.Sprintf("test%02d", i),
-				"externalId": fmt.Sprintf("external%02d", i),
-				"name": map[string]interface{}{
-					"familyName": fmt.Sprintf("familyName%02d", i),
-					"givenName":  fmt.Sprintf("givenName%02d", i),
-				},
-				"active": true,
-				"emails": []map[string]interface{}{
-					{
-						"value": fmt.Sprintf("%02d@example.com", i),
-					},
-				},
+		created, _ := time.Parse(time.RFC3339, fmt.Sprintf("2020-01-%02dT15:04:05+07:00", i))
+		lastModified, _ := time.Parse(time.RFC3339, fmt.Sprintf("2020-02-%02dT16:05:04+07:00", i))
+		attributes := scim.ResourceAttributes{
+			"userName":   fmt.Sprintf("test%02d", i),
+			"externalId": fmt.Sprintf("external%02d", i),
+			"name": map[string]interface{}{
+				"familyName": fmt.Sprintf("familyName%02d", i),
+				"givenName":  fmt.Sprintf("givenName%02d", i),
 			},
-			meta: map[string]string{
-				"created":      fmt.Sprintf("2020-01-%02dT15:04:05+07:00", i),
-				"lastModified": fmt.Sprintf("2020-02-%02dT16:05:04+07:00", i),
-				"version":      fmt.Sprintf("v%09d", i),
+			"active": true,
+			"emails": []interface{}{
+				map[string]interface{}{
+					"value": fmt.Sprintf("%02d@example.com", i),
+				},
 			},
 		}
-	}
-
-	return ResourceHandler{
-		data: data,
+		if h.schema.id == schema.CoreGroupSchema().ID {
+			attributes = scim.ResourceAttributes{
+				"displayName": fmt.Sprintf("group%02d", i),
+				"externalId":  fmt.Sprintf("external%02d", i),
+			}
+		}
+		h.store(h.newID(), Data{
+			resourceAttributes: attributes,
+			meta: meta{
+				created:      created,
+				lastModified: lastModified,
+			},
+		})
 	}
 }
 
 type Data struct {
 	resourceAttributes scim.ResourceAttributes
-	meta               map[string]string
+	meta               meta
+}
+
+type meta struct {
+	created      time.Time
+	lastModified time.Time
+	version      int
 }
 
-// simple in-memory resource database
+// etag returns the version of the resource as a weak entity tag.
+func (m meta) etag() string {
+	return fmt.Sprintf("W/\"%d\"", m.version)
+}
+
+// directory is shared by the resource handlers of a server. Identifiers are unique over all handlers, so the members
+// of a group can refer to both users and groups.
+type directory struct {
+	// mu guards all handlers of the server, memberships span more than one handler.
+	mu     sync.RWMutex
+	lastID int
+	// users and groups are the handlers of the "/Users" and "/Groups" endpoints.
+	users, groups *ResourceHandler
+}
+
+// simple in-memory resource database, safe for concurrent use. Resources are listed in the order they were created.
 type ResourceHandler struct {
+	*directory
 	data map[string]Data
+	ids  []string
+	// revision is incremented on every change, it is used as the version of the changed resource.
+	revision int
+	schema   resourceSchema
+	features *Features
+	// caseExactUserName makes the uniqueness of user names case sensitive, used to inject a fault.
+	caseExactUserName bool
+	// file is the file the resources are persisted in, they are only kept in memory if it is empty.
+	file string
 }
 
-func (h ResourceHandler) Create(r *http.Request, attributes scim.ResourceAttributes) (scim.Resource, error) {
-	// create unique identifier
-	rand.Seed(time.Now().UnixNano())
-	id := fmt.Sprintf("%04d", rand.Intn(9999))
+// newID returns a new unique identifier, the caller must hold the lock.
+func (h *ResourceHandler) newID() string {
+	h.lastID++
+	return fmt.Sprintf("%04d", h.lastID)
+}
 
-	for _, entity := range h.data {
-		uN1, ok1 := entity.resourceAttributes["userName"]
-		uN2, ok2 := attributes["userName"]
-		if ok1 && ok2 && uN1 == uN2 {
-			return scim.Resource{}, errors.ScimErrorUniqueness
-		}
+// store stores the resource and bumps its version, the caller must hold the lock.
+func (h *ResourceHandler) store(id string, data Data) {
+	if _, ok := h.data[id]; !ok {
+		h.ids = append(h.ids, id)
 	}
+	h.revision++
+	data.meta.version = h.revision
+	h.data[id] = data
+}
 
-	// store resource
-	h.data[id] = Data{
-		resourceAttributes: attributes,
+func (h *ResourceHandler) Create(r *http.Request, attributes scim.ResourceAttributes) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.unique("", attributes); err != nil {
+		return scim.Resource{}, err
 	}
 
-	now := time.Now()
+	// derived attributes are ignored, members have to refer to existing users or groups.
+	attributes = clone(attributes).(scim.ResourceAttributes)
+	h.readOnly(attributes)
+	if err := h.resolveMembers("", attributes); err != nil {
+		return scim.Resource{}, err
+	}
 
-	// return stored resource
-	return scim.Resource{
-		ID:         id,
-		ExternalID: h.externalID(attributes),
-		Attributes: attributes,
-		Meta: scim.Meta{
-			Created:      &now,
-			LastModified: &now,
-			Version:      fmt.Sprintf("v%s", id),
+	// store resource
+	id := h.newID()
+	now := time.Now().UTC()
+	h.store(id, Data{
+		resourceAttributes: attributes,
+		meta: meta{
+			created:      now,
+			lastModified: now,
 		},
-	}, nil
+	})
+	h.save()
+
+	// return stored resource
+	return h.resource(r, id), nil
 }
 
-func (h ResourceHandler) Get(r *http.Request, id string) (scim.Resource, error) {
+func (h *ResourceHandler) Get(r *http.Request, id string) (scim.Resource, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	// check if resource exists
-	data, ok := h.data[id]
-	if !ok {
+	if _, ok := h.data[id]; !ok {
 		return scim.Resource{}, errors.ScimErrorResourceNotFound(id)
 	}
 
-	created, _ := time.ParseInLocation(time.RFC3339, fmt.Sprintf("%v", data.meta["created"]), time.UTC)
-	lastModified, _ := time.Parse(time.RFC3339, fmt.Sprintf("%v", data.meta["lastModified"]))
-
 	// return resource with given identifier
-	return scim.Resource{
-		ID:         id,
-		ExternalID: h.externalID(data.resourceAttributes),
-		Attributes: data.resourceAttributes,
-		Meta: scim.Meta{
-			Created:      &created,
-			LastModified: &lastModified,
-			Version:      fmt.Sprintf("%v", data.meta["version"]),
-		},
-	}, nil
+	return h.resource(r, id), nil
 }
 
-func (h ResourceHandler) GetAll(r *http.Request, params scim.ListRequestParams) (scim.Page, error) {
-	data := h.data
+func (h *ResourceHandler) GetAll(r *http.Request, params scim.ListRequestParams) (scim.Page, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ids := h.ids
 	if params.Filter != nil {
-		filteredData := make(map[string]Data)
-		for k, v := range h.data {
-			// Only 'x eq userName' is implemented
-			switch params.Filter.(type) {
-			case filter.AttributeExpression:
-				attrExp := params.Filter.(filter.AttributeExpression)
-				if attrExp.CompareOperator == filter.EQ {
-					switch name := attrExp.AttributePath.AttributeName; name {
-					case "userName":
-						if attrExp.CompareValue == h.userName(v.resourceAttributes) {
-							filteredData[k] = v
-						}
-					case "displayName":
-						if attrExp.CompareValue == h.displayName(v.resourceAttributes) {
-							filteredData[k] = v
-						}
-					}
-				}
+		ids = nil
+		for _, id := range h.ids {
+			match, err := h.schema.match(h.filterable(id), params.Filter)
+			if err != nil {
+				return scim.Page{}, err
+			}
+			if match {
+				ids = append(ids, id)
 			}
 		}
-		data = filteredData
 	}
-	i := 1
-	resources := make([]scim.Resource, 0)
-	for k, v := range data {
-		if i > (params.StartIndex + params.Count - 1) {
-			break
+
+	if sortBy := r.URL.Query().Get("sortBy"); sortBy != "" && h.features.Sort {
+		var err error
+		if ids, err = h.sort(ids, sortBy, r.URL.Query().Get("sortOrder")); err != nil {
+			return scim.Page{}, err
 		}
+	}
 
-		if i >= params.StartIndex {
-			resources = append(resources, scim.Resource{
-				ID:         k,
-				ExternalID: h.externalID(v.resourceAttributes),
-				Attributes: v.resourceAttributes,
-			})
+	resources := make([]scim.Resource, 0)
+	for i, id := range ids {
+		if i+1 < params.StartIndex {
+			continue
 		}
-		i++
+		if len(resources) >= params.Count {
+			break
+		}
+		resources = append(resources, h.resource(r, id))
 	}
 
 	return scim.Page{
-		TotalResults: len(data),
+		TotalResults: len(ids),
 		Resources:    resources,
 	}, nil
 }
 
-func (h ResourceHandler) Replace(r *http.Request, id string, attributes scim.ResourceAttributes) (scim.Resource, error) {
+func (h *ResourceHandler) Replace(r *http.Request, id string, attributes scim.ResourceAttributes) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	// check if resource exists
-	_, ok := h.data[id]
+	data, ok := h.data[id]
 	if !ok {
 		return scim.Resource{}, errors.ScimErrorResourceNotFound(id)
 	}
+	if err := h.precondition(r, data); err != nil {
+		return scim.Resource{}, err
+	}
+	if err := h.changePassword(data.resourceAttributes, attributes); err != nil {
+		return scim.Resource{}, err
+	}
+	if err := h.unique(id, attributes); err != nil {
+		return scim.Resource{}, err
+	}
 
 	// replace (all) attributes
-	h.data[id] = Data{
-		resourceAttributes: attributes,
+	attributes = clone(attributes).(scim.ResourceAttributes)
+	h.readOnly(attributes)
+	if err := h.resolveMembers(id, attributes); err != nil {
+		return scim.Resource{}, err
 	}
+	data.resourceAttributes = attributes
+	data.meta.lastModified = time.Now().UTC()
+	h.store(id, data)
+	h.save()
 
 	// return resource with replaced attributes
-	return scim.Resource{
-		ID:         id,
-		ExternalID: h.externalID(attributes),
-		Attributes: attributes,
-	}, nil
+	return h.resource(r, id), nil
 }
 
-func (h ResourceHandler) Delete(r *http.Request, id string) error {
+func (h *ResourceHandler) Delete(r *http.Request, id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	// check if resource exists
-	_, ok := h.data[id]
+	data, ok := h.data[id]
 	if !ok {
 		return errors.ScimErrorResourceNotFound(id)
 	}
+	if err := h.precondition(r, data); err != nil {
+		return err
+	}
 
 	// delete resource
 	delete(h.data, id)
+	for i, v := range h.ids {
+		if v == id {
+			h.ids = append(h.ids[:i:i], h.ids[i+1:]...)
+			break
+		}
+	}
+	h.save()
+	h.removeMember(id)
 
 	return nil
 }
 
-func (h ResourceHandler) Patch(r *http.Request, id string, req scim.PatchRequest) (scim.Resource, error) {
-	for _, op := range req.Operations {
-		switch op.Op {
-		case scim.PatchOperationAdd:
-			if op.Path != "" {
-				h.data[id].resourceAttributes[op.Path] = op.Value
-			} else {
-				valueMap := op.Value.(map[string]interface{})
-				for k, v := range valueMap {
-					if arr, ok := h.data[id].resourceAttributes[k].([]interface{}); ok {
-						arr = append(arr, v)
-						h.data[id].resourceAttributes[k] = arr
-					} else {
-						h.data[id].resourceAttributes[k] = v
-					}
-				}
-			}
-		case scim.PatchOperationReplace:
-			if op.Path != "" {
-				h.data[id].resourceAttributes[op.Path] = op.Value
-			} else {
-				valueMap := op.Value.(map[string]interface{})
-				for k, v := range valueMap {
-					h.data[id].resourceAttributes[k] = v
-				}
-			}
-		case scim.PatchOperationRemove:
-			h.data[id].resourceAttributes[op.Path] = nil
-		}
+func (h *ResourceHandler) Patch(r *http.Request, id string, req scim.PatchRequest) (scim.Resource, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// check if resource exists
+	data, ok := h.data[id]
+	if !ok {
+		return scim.Resource{}, errors.ScimErrorResourceNotFound(id)
+	}
+	if err := h.precondition(r, data); err != nil {
+		return scim.Resource{}, err
+	}
+
+	// patch a copy, so the resource is left untouched if one of the operations fails
+	attributes := clone(data.resourceAttributes).(scim.ResourceAttributes)
+	if err := h.schema.patch(attributes, withoutID(id, req.Operations)); err != nil {
+		return scim.Resource{}, err
+	}
+	if err := h.resolveMembers(id, attributes); err != nil {
+		return scim.Resource{}, err
+	}
+	if err := h.changePassword(data.resourceAttributes, attributes); err != nil {
+		return scim.Resource{}, err
+	}
+	if err := h.unique(id, attributes); err != nil {
+		return scim.Resource{}, err
 	}
 
-	created, _ := time.ParseInLocation(time.RFC3339, fmt.Sprintf("%v", h.data[id].meta["created"]), time.UTC)
-	now := time.Now()
+	data.resourceAttributes = attributes
+	data.meta.lastModified = time.Now().UTC()
+	h.store(id, data)
+	h.save()
 
 	// return resource with replaced attributes
+	return h.resource(r, id), nil
+}
+
+// unique checks that no other resource than the one with the given id has the same user name (case insensitive), the
+// caller must hold the lock.
+func (h *ResourceHandler) unique(id string, attributes scim.ResourceAttributes) error {
+	userName, ok := attributes["userName"].(string)
+	if !ok {
+		return nil
+	}
+	for other, entity := range h.data {
+		uN, ok := entity.resourceAttributes["userName"].(string)
+		if other != id && ok && (uN == userName || !h.caseExactUserName && strings.EqualFold(uN, userName)) {
+			return errors.ScimErrorUniqueness
+		}
+	}
+	return nil
+}
+
+// precondition checks the If-Match header of the request against the version of the resource.
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.14
+func (h *ResourceHandler) precondition(r *http.Request, data Data) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || !h.features.ETag {
+		return nil
+	}
+	for _, etag := range strings.Split(ifMatch, ",") {
+		// weak comparison, versions are weak entity tags.
+		etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+		if etag == "*" || etag == strings.TrimPrefix(data.meta.etag(), "W/") {
+			return nil
+		}
+	}
+	return errors.ScimError{
+		Detail: "The resource has been modified.",
+		Status: http.StatusPreconditionFailed,
+	}
+}
+
+// changePassword checks whether the password of the resource may be changed.
+func (h *ResourceHandler) changePassword(old, new scim.ResourceAttributes) error {
+	if h.features.ChangePassword {
+		return nil
+	}
+	if password := get(new, "password"); password != nil && !reflect.DeepEqual(password, get(old, "password")) {
+		return errors.ScimErrorMutability
+	}
+	return nil
+}
+
+// resource returns a copy of the stored resource, without the attributes that are never returned. The caller must
+// hold the lock.
+func (h *ResourceHandler) resource(r *http.Request, id string) scim.Resource {
+	data := h.data[id]
+	created, lastModified := data.meta.created, data.meta.lastModified
+	attributes := clone(data.resourceAttributes).(scim.ResourceAttributes)
+	h.references(r, id, attributes)
+	for _, attr := range h.schema.attributes {
+		if attr.Returned == "never" {
+			remove(attributes, attr.Name)
+		}
+	}
 	return scim.Resource{
 		ID:         id,
-		ExternalID: h.externalID(h.data[id].resourceAttributes),
-		Attributes: h.data[id].resourceAttributes,
+		ExternalID: h.externalID(data.resourceAttributes),
+		Attributes: attributes,
 		Meta: scim.Meta{
 			Created:      &created,
-			LastModified: &now,
-			Version:      fmt.Sprintf("%s.patch", h.data[id].meta["version"]),
+			LastModified: &lastModified,
+			Version:      data.meta.etag(),
 		},
-	}, nil
+	}
 }
 
-func (h ResourceHandler) externalID(attributes scim.ResourceAttributes) optional.String {
+func (h *ResourceHandler) externalID(attributes scim.ResourceAttributes) optional.String {
 	if eID, ok := attributes["externalId"]; ok {
 		externalID, ok := eID.(string)
 		if !ok {
@@ -291,22 +438,66 @@ func (h ResourceHandler) externalID(attributes scim.ResourceAttributes) optional
 	return optional.String{}
 }
 
-func (h ResourceHandler) userName(attributes scim.ResourceAttributes) string {
-	if uN, ok := attributes["userName"]; ok {
-		if userName, ok := uN.(string); ok {
-			return userName
-		}
+// filterable returns the attributes of the resource, including its id and meta attributes, to evaluate filters on.
+func (h *ResourceHandler) filterable(id string) map[string]interface{} {
+	data := h.data[id]
+	resource := map[string]interface{}{
+		"id": id,
+		"meta": map[string]interface{}{
+			"created":      data.meta.created,
+			"lastModified": data.meta.lastModified,
+			"version":      data.meta.etag(),
+		},
 	}
-
-	return ""
+	for k, v := range data.resourceAttributes {
+		resource[k] = v
+	}
+	if h == h.users {
+		resource["groups"] = h.memberOf(id)
+	}
+	return resource
 }
 
-func (h ResourceHandler) displayName(attributes scim.ResourceAttributes) string {
-	if dN, ok := attributes["displayName"]; ok {
-		if displayName, ok := dN.(string); ok {
-			return displayName
+// readOnly removes the read-only attributes that are derived by the server from the given attributes.
+func (h *ResourceHandler) readOnly(attributes scim.ResourceAttributes) {
+	if h == h.users {
+		remove(attributes, "groups")
+	}
+	if extension, ok := get(attributes, schema.ExtensionEnterpriseUser().ID).(map[string]interface{}); ok {
+		if manager, ok := get(extension, "manager").(map[string]interface{}); ok {
+			remove(manager, "displayName")
 		}
 	}
+}
 
-	return ""
+// clone returns a deep copy of the given attribute value, so stored resources are never shared with callers.
+func clone(v interface{}) interface{} {
+	switch v := v.(type) {
+	case scim.ResourceAttributes:
+		c := make(scim.ResourceAttributes, len(v))
+		for k, e := range v {
+			c[k] = clone(e)
+		}
+		return c
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = clone(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = clone(e)
+		}
+		return c
+	case []map[string]interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = clone(e)
+		}
+		return c
+	default:
+		return v
+	}
 }
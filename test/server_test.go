@@ -0,0 +1,53 @@
+package test_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/test"
+)
+
+// TestUniqueUserName checks that user names stay unique (case insensitive) on a create, replace and patch, while a
+// user can keep its own user name.
+func TestUniqueUserName(t *testing.T) {
+	server := httptest.NewServer(test.Server())
+	defer server.Close()
+
+	do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "bjensen"
+	}`)
+	user := do(t, http.MethodPost, server.URL+"/Users", http.StatusCreated, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "jsmith"
+	}`)
+	userID := user["id"].(string)
+	do(t, http.MethodPost, server.URL+"/Users", http.StatusConflict, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "BJensen"
+	}`)
+
+	for _, userName := range []string{"bjensen", "BJENSEN"} {
+		do(t, http.MethodPut, server.URL+"/Users/"+userID, http.StatusConflict, `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": "`+userName+`"
+		}`)
+		do(t, http.MethodPatch, server.URL+"/Users/"+userID, http.StatusConflict, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "userName", "value": "`+userName+`"}]
+		}`)
+	}
+	if user = do(t, http.MethodGet, server.URL+"/Users/"+userID, http.StatusOK, ""); user["userName"] != "jsmith" {
+		t.Errorf("expected the user name to be left untouched, got %v", user["userName"])
+	}
+
+	do(t, http.MethodPut, server.URL+"/Users/"+userID, http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "JSmith"
+	}`)
+	do(t, http.MethodPatch, server.URL+"/Users/"+userID, http.StatusOK, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "displayName", "value": "John"}]
+	}`)
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/elimity-com/scim/errors"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.4.2.3
+
+// sort sorts the resources with the given ids by the value of the sortBy attribute. Resources without a value are
+// always sorted last. The caller must hold the lock.
+func (h *ResourceHandler) sort(ids []string, sortBy, sortOrder string) ([]string, error) {
+	var descending bool
+	switch sortOrder {
+	case "", "ascending":
+	case "descending":
+		descending = true
+	default:
+		return nil, errors.ScimErrorInvalidValue
+	}
+
+	path, err := h.schema.parsePath(sortBy)
+	if err != nil || path.attribute == "" || path.filter != nil {
+		return nil, errors.ScimErrorInvalidPath
+	}
+
+	type item struct {
+		id    string
+		value interface{}
+	}
+	var (
+		attr  attribute
+		items = make([]item, len(ids))
+	)
+	for i, id := range ids {
+		a, v, err := h.schema.sortValue(h.filterable(id), path)
+		if err != nil {
+			return nil, err
+		}
+		attr, items[i] = a, item{id: id, value: v}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].value, items[j].value
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		cmp := compareValues(attr, a, b)
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	sorted := make([]string, len(items))
+	for i, item := range items {
+		sorted[i] = item.id
+	}
+	return sorted, nil
+}
+
+// sortValue returns the value of the resource to sort on and the attribute it belongs to. For multi valued attributes
+// the primary (or first) value is used, complex attributes are sorted by their "value" sub attribute.
+func (s resourceSchema) sortValue(resource map[string]interface{}, path patchPath) (attribute, interface{}, error) {
+	attributes, object, ok := s.scope(path.uri, resource)
+	if !ok {
+		return attribute{}, nil, errors.ScimErrorInvalidPath
+	}
+	attr, ok := find(attributes, path.attribute)
+	if !ok {
+		return attribute{}, nil, errors.ScimErrorInvalidPath
+	}
+
+	v := get(object, attr.Name)
+	if attr.MultiValued {
+		vs := values(v)
+		v = nil
+		for i, e := range vs {
+			if m, ok := e.(map[string]interface{}); i == 0 || ok && m["primary"] == true {
+				v = e
+			}
+		}
+	}
+
+	sub := path.subAttribute
+	if sub == "" && attr.Type == "complex" {
+		sub = "value"
+	}
+	if sub != "" {
+		subAttr, ok := attr.subAttribute(sub)
+		if !ok {
+			return attribute{}, nil, errors.ScimErrorInvalidPath
+		}
+		m, _ := v.(map[string]interface{})
+		attr, v = subAttr, get(m, sub)
+	}
+	return attr, v, nil
+}
+
+// compareValues compares two (singular) values of the given attribute, it returns -1, 0 or +1.
+func compareValues(attr attribute, a, b interface{}) int {
+	switch attr.Type {
+	case "boolean":
+		x, _ := toBool(a)
+		y, _ := toBool(b)
+		switch {
+		case x == y:
+			return 0
+		case y:
+			return -1
+		default:
+			return 1
+		}
+	case "integer", "decimal":
+		x, _ := toFloat(a)
+		y, _ := toFloat(b)
+		return compareFloat(x, y)
+	case "dateTime":
+		x, _ := toTime(a)
+		y, _ := toTime(b)
+		return compareTime(x, y)
+	default:
+		x, _ := a.(string)
+		y, _ := b.(string)
+		if !attr.CaseExact {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+		return strings.Compare(x, y)
+	}
+}
@@ -0,0 +1,49 @@
+package test_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// expectedFailures are the tests that fail against the reference server because it is knowingly incomplete, with the
+// reason why. The names are prefixed with the name of their suite, like the tests that are paired with a fault.
+var expectedFailures = map[string]string{
+	"scim/TestServiceProviderConfigurationEndpoints/ForbiddenFilter": "" +
+		"the scim package serves /Schemas and /ResourceTypes and ignores the filter instead of responding with 403.",
+}
+
+// TestSuites runs all suites against the reference server without faults. Every failure has to be an expected failure,
+// and every expected failure has to fail.
+func TestSuites(t *testing.T) {
+	all, err := runFaultySuites(noFault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failures := make(map[string]bool)
+	for _, failure := range leaves(all) {
+		failures[failure] = true
+		if _, ok := expectedFailures[failure]; !ok {
+			t.Errorf("unexpected failure: %s", failure)
+		}
+	}
+	for name, reason := range expectedFailures {
+		if !failures[name] {
+			t.Errorf("expected failure passed, remove it from the list: %s (%s)", name, reason)
+		}
+	}
+}
+
+// leaves returns the failed tests without their parents, which fail because one of their subtests failed.
+func leaves(failures []string) []string {
+	sort.Strings(failures)
+	var leaves []string
+	for i, failure := range failures {
+		if i+1 < len(failures) && strings.HasPrefix(failures[i+1], failure+"/") {
+			continue
+		}
+		leaves = append(leaves, failure)
+	}
+	return leaves
+}
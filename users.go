@@ -0,0 +1,46 @@
+package suite
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/di-wu/scim-test-suite/util"
+)
+
+// RFC: https://tools.ietf.org/html/rfc7644#section-3.3
+
+func (suite *SCIMTestSuite) TestCreateUser() {
+	var (
+		userName = suite.Namespaced("bjensen")
+		resp     = suite.Post("/Users", suite.userBody(userName))
+	)
+
+	suite.Run("Created", func() {
+		// When the service provider successfully creates the new resource, an HTTP response SHALL be returned with HTTP
+		// status code 201 (Created).
+		suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+	})
+
+	suite.Run("Location", func() {
+		// The URI of the created resource SHALL be included in the HTTP "Location" header.
+		var (
+			user     = suite.ReadAllToMap(resp)
+			id       = suite.GetString("id", user)
+			location = resp.Header.Get("Location")
+		)
+		suite.Require().NotEmpty(location)
+		suite.True(strings.HasSuffix(location, fmt.Sprintf("/Users/%s", id)), location)
+	})
+
+	suite.Run("UserNameUniqueness", func() {
+		// The user name is case insensitive and unique, a duplicate user name results in 409 (Conflict).
+		resp := suite.Post("/Users", suite.userBody(strings.ToUpper(userName)))
+		suite.Equal(http.StatusConflict, resp.StatusCode)
+	})
+}
+
+func (suite *SCIMTestSuite) userBody(userName string) *bytes.Reader {
+	return suite.Body(util.UserBody(userName))
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Body returns the JSON encoding of the given value as a request body, it fails the test if the value can not be
+// encoded.
+func (suite *Suite) Body(v interface{}) *bytes.Reader {
+	body, err := json.Marshal(v)
+	suite.Require().NoError(err)
+	return bytes.NewReader(body)
+}
+
+// The request bodies below are shared by the suites and the load mode.
+
+// UserBody returns the body of a new user with the given user name.
+func UserBody(userName string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName": userName,
+	}
+}
+
+// GroupBody returns the body of a new group with the given display name and members.
+func GroupBody(displayName string, members ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:Group"},
+		"displayName": displayName,
+		"members":     members,
+	}
+}
+
+// PatchBody returns the body of a PATCH request with the given operations.
+func PatchBody(operations ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": operations,
+	}
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// KeepResources disables the removal of the resources that were created during the run of the suite.
+func (suite *Suite) KeepResources(keep bool) {
+	suite.keepResources = keep
+}
+
+// track records the resource that was created by a successful POST to the given path. The location is taken from the
+// "Location" header, or from the "id" in the body if the header is missing.
+func (suite *Suite) track(path string, resp *http.Response) {
+	location, ok := suite.relative(resp.Header.Get("Location"))
+	if !ok {
+		raw, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return
+		}
+		// restore the body, so it can still be read by the test.
+		resp.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
+		var resource struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &resource); err != nil || resource.ID == "" {
+			return
+		}
+		location = fmt.Sprintf("%s/%s", strings.SplitN(path, "?", 2)[0], resource.ID)
+	}
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.resources = append(suite.resources, location)
+}
+
+// relative returns the path of the given location relative to the base URL, e.g. "/Users/1" for both
+// "https://example.com/v2/Users/1" and "/v2/Users/1" if the base URL is "https://example.com/v2". It returns false if
+// the location is empty or does not refer to a resource of the base URL.
+func (suite *Suite) relative(location string) (string, bool) {
+	if location == "" {
+		return "", false
+	}
+	base, err := url.Parse(suite.url + "/")
+	if err != nil {
+		return "", false
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != base.Scheme || resolved.Host != base.Host {
+		return "", false
+	}
+	basePath := strings.TrimSuffix(base.Path, "/")
+	if !strings.HasPrefix(resolved.Path, basePath+"/") || len(resolved.Path) == len(basePath)+1 {
+		return "", false
+	}
+	return resolved.Path[len(basePath):], true
+}
+
+// TearDownSuite removes all resources that were created during the run of the suite, groups before users. Resources
+// that could not be removed are logged.
+func (suite *Suite) TearDownSuite() {
+	suite.mu.Lock()
+	resources := suite.resources
+	suite.resources = nil
+	suite.mu.Unlock()
+
+	if suite.keepResources || len(resources) == 0 {
+		return
+	}
+
+	for i, j := 0, len(resources)-1; i < j; i, j = i+1, j-1 {
+		resources[i], resources[j] = resources[j], resources[i]
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return strings.HasPrefix(resources[i], "/Groups") && !strings.HasPrefix(resources[j], "/Groups")
+	})
+
+	var leftovers []string
+	for _, location := range resources {
+		if err := suite.remove(location); err != nil {
+			leftovers = append(leftovers, fmt.Sprintf("%s: %v", location, err))
+		}
+	}
+	if len(leftovers) != 0 {
+		suite.T().Logf("failed to remove %d resource(s):\n\t%s", len(leftovers), strings.Join(leftovers, "\n\t"))
+	}
+}
+
+// remove deletes the resource at the given location. Resources that are already removed are ignored.
+func (suite *Suite) remove(location string) error {
+	req, err := http.NewRequest(http.MethodDelete, suite.url+location, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := suite.do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
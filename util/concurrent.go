@@ -0,0 +1,97 @@
+package util
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Result is the outcome of a request that was sent by Concurrently. The assertions of the suite are not safe to use
+// from other goroutines, so the results are collected and asserted on afterwards.
+type Result struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Err is the error of the request if no response was received.
+	Err error
+}
+
+// Results are the results of the requests that were sent by Concurrently, in the order of the requests.
+type Results []Result
+
+// Count returns the number of responses with the given status code.
+func (results Results) Count(status int) int {
+	var n int
+	for _, result := range results {
+		if result.Err == nil && result.StatusCode == status {
+			n++
+		}
+	}
+	return n
+}
+
+// StatusCodes returns the status codes of the responses, requests without a response are left out.
+func (results Results) StatusCodes() []int {
+	var codes []int
+	for _, result := range results {
+		if result.Err == nil {
+			codes = append(codes, result.StatusCode)
+		}
+	}
+	return codes
+}
+
+// Err returns the first error of a request without a response, if any.
+func (results Results) Err() error {
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// NewRequest returns a request to the given path of the server, with a SCIM content type if it has a body.
+func (suite *Suite) NewRequest(method, path string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, suite.url+path, body)
+	suite.Require().NoError(err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	return req
+}
+
+// Concurrently sends the given requests at the same time and returns their results. The resources that are created
+// get removed when the suite is torn down, like those of Do.
+func (suite *Suite) Concurrently(reqs ...*http.Request) Results {
+	var (
+		results = make(Results, len(reqs))
+		start   = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *http.Request) {
+			defer wg.Done()
+			<-start
+
+			resp, err := suite.do(req)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer func() { _ = resp.Body.Close() }()
+			body, err := ioutil.ReadAll(resp.Body)
+			results[i] = Result{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header,
+				Body:       body,
+				Err:        err,
+			}
+		}(i, req)
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
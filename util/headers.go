@@ -0,0 +1,30 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers is a repeatable flag of "Key: Value" headers to add to every request of a command.
+type Headers []string
+
+func (h *Headers) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *Headers) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("invalid header %q, expected \"Key: Value\"", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// Apply sets the headers on the given request.
+func (h Headers) Apply(req *http.Request) {
+	for _, header := range h {
+		kv := strings.SplitN(header, ":", 2)
+		req.Header.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NamespacePrefix is the prefix of all generated namespaces. It can be used to sweep the resources of all runs that
+// were not removed.
+const NamespacePrefix = "scimtest-"
+
+// SetNamespace sets the prefix that gets added to the names of all resources that are created by the suite.
+func (suite *Suite) SetNamespace(namespace string) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.namespace = namespace
+}
+
+// Namespace returns the prefix of the names of all resources that are created by the suite. If none is set, a unique
+// one is generated for the run, e.g. "scimtest-3f9a1c-".
+func (suite *Suite) Namespace() string {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	if suite.namespace == "" {
+		b := make([]byte, 3)
+		_, _ = rand.Read(b)
+		suite.namespace = NamespacePrefix + hex.EncodeToString(b) + "-"
+	}
+	return suite.namespace
+}
+
+// Namespaced returns the given name prefixed with the namespace of the run.
+func (suite *Suite) Namespaced(name string) string {
+	return suite.Namespace() + name
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"io"
 	"net/http"
+	"strings"
 )
 
 func (suite *Suite) Delete(path string) *http.Response {
@@ -13,6 +14,16 @@ func (suite *Suite) Get(path string) *http.Response {
 	return suite.receive(path, http.MethodGet)
 }
 
+// GetReference requests the resource of the given "$ref" URI, relative references are resolved against the base URL.
+func (suite *Suite) GetReference(ref string) *http.Response {
+	if !strings.Contains(ref, "://") {
+		ref = suite.url + "/" + strings.TrimPrefix(ref, "/")
+	}
+	req, err := http.NewRequest(http.MethodGet, ref, nil)
+	suite.Require().NoError(err)
+	return suite.Do(req)
+}
+
 func (suite *Suite) receive(path string, method string) *http.Response {
 	req, err := http.NewRequest(method, suite.url+path, nil)
 	suite.Require().NoError(err)
@@ -38,17 +49,31 @@ func (suite *Suite) Put(path string, body io.Reader) *http.Response {
 }
 
 func (suite *Suite) send(path string, body io.Reader, method string) *http.Response {
-	req, err := http.NewRequest(method, suite.url+path, body)
-	suite.Require().NoError(err)
-	req.Header.Set("Content-Type", "application/scim+json")
-	return suite.Do(req)
+	return suite.Do(suite.NewRequest(method, path, body))
 }
 
+// Do sends the request to the server. The resources that are created get removed when the suite is torn down.
 func (suite *Suite) Do(req *http.Request) *http.Response {
+	resp, err := suite.do(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *Suite) do(req *http.Request) (*http.Response, error) {
 	if suite.middleware != nil {
 		req = suite.middleware(req)
 	}
 	resp, err := http.DefaultClient.Do(req)
-	suite.Require().NoError(err)
-	return resp
+	if err == nil && suite.retries != 0 {
+		resp, err = suite.retry(req, resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	target := req.URL.String()
+	if req.Method == http.MethodPost && resp.StatusCode == http.StatusCreated && strings.HasPrefix(target, suite.url) {
+		suite.track(strings.TrimPrefix(target, suite.url), resp)
+	}
+	return resp, nil
 }
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/di-wu/scim-test-suite/report"
+	"github.com/stretchr/testify/assert"
+)
+
+// Strict makes SHOULD and MAY deviations fail the test, by default they are reported as warnings.
+func (suite *Suite) Strict(strict bool) {
+	suite.strict = strict
+}
+
+// Should returns the assertions for SHOULD-level requirements.
+func (suite *Suite) Should() *assert.Assertions {
+	return suite.Level(report.SHOULD)
+}
+
+// May returns the assertions for MAY-level requirements.
+func (suite *Suite) May() *assert.Assertions {
+	return suite.Level(report.MAY)
+}
+
+// Level returns the assertions for requirements of the given level. Failures of MUST-level requirements always fail
+// the test, others only get logged as a warning unless the suite is strict.
+func (suite *Suite) Level(level report.Level) *assert.Assertions {
+	if level == report.MUST || suite.strict {
+		return suite.Assert()
+	}
+	return assert.New(warning{
+		T:     suite.T(),
+		level: level,
+	})
+}
+
+// warning is a testing.T that logs errors instead of failing the test. Its Helper method is the one of the wrapped
+// testing.T, so the warnings are reported at the failing assertion.
+type warning struct {
+	*testing.T
+	level report.Level
+}
+
+func (w warning) Errorf(format string, args ...interface{}) {
+	w.Helper()
+	w.Logf("%s (%s): %s", report.WarningPrefix, w.level, fmt.Sprintf(format, args...))
+}
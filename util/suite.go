@@ -7,12 +7,20 @@ import (
 	"github.com/stretchr/testify/suite"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 type Suite struct {
 	suite.Suite
 	url        string
 	middleware func(req *http.Request) *http.Request
+	strict     bool
+	retries    int
+
+	mu            sync.Mutex
+	namespace     string
+	resources     []string
+	keepResources bool
 
 	attrNameValidator operators.Operator
 }
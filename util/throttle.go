@@ -0,0 +1,130 @@
+package util
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// backoff is the delay before the first retry of a throttled request without a Retry-After header, it doubles
+	// with every retry.
+	backoff = 500 * time.Millisecond
+	// maxBackoff is the maximum delay before a retry of a throttled request.
+	maxBackoff = 30 * time.Second
+)
+
+// RetryThrottled retries requests that got a 429 (Too Many Requests) or 503 (Service Unavailable) up to the given
+// number of times, after the delay of their Retry-After header or with an exponential backoff.
+func (suite *Suite) RetryThrottled(retries int) {
+	suite.retries = retries
+}
+
+// throttled returns whether the response indicates that the request was throttled.
+func throttled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// retry resends the throttled request after waiting, until it is no longer throttled or it runs out of retries.
+func (suite *Suite) retry(req *http.Request, resp *http.Response) (*http.Response, error) {
+	for attempt := 0; attempt < suite.retries && throttled(resp); attempt++ {
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			// the body can not be sent again.
+			return resp, nil
+		}
+
+		delay := backoff << uint(attempt)
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+		delay = RetryAfter(resp.Header.Get("Retry-After"), delay)
+		if delay > maxBackoff {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		suite.T().Logf("%s %s got %d, retrying after %s", req.Method, req.URL.Path, resp.StatusCode, delay)
+		time.Sleep(delay)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		var err error
+		if resp, err = http.DefaultClient.Do(req); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Burst sends concurrent GET requests to the given path until one of them is rate limited (429), or the ceiling is
+// reached. It returns the rate limited response (or nil) and the number of requests that were sent, without retries.
+func (suite *Suite) Burst(path string, ceiling int) (*http.Response, int) {
+	const workers = 8
+	var (
+		mu      sync.Mutex
+		sent    int
+		limited *http.Response
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if limited != nil || sent >= ceiling {
+					mu.Unlock()
+					return
+				}
+				sent++
+				mu.Unlock()
+
+				req, err := http.NewRequest(http.MethodGet, suite.url+path, nil)
+				if err != nil {
+					return
+				}
+				if suite.middleware != nil {
+					req = suite.middleware(req)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					continue
+				}
+
+				mu.Lock()
+				if resp.StatusCode == http.StatusTooManyRequests && limited == nil {
+					limited = resp
+					mu.Unlock()
+					continue
+				}
+				mu.Unlock()
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	return limited, sent
+}
+
+// RetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date. The
+// fallback is returned if the value is missing or invalid.
+func RetryAfter(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return fallback
+}